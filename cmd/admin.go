@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"tender-backend/internal/pkg/config"
+	server "tender-backend/internal/storage/repo"
+	db "tender-backend/internal/usecase/postgres"
+)
+
+// createAdmin implements the create-admin command. Admins cannot register
+// through the API, so this is the only way to create one:
+//
+//	main create-admin -email admin@example.com -username admin -full-name "Site Admin"
+//
+// The password is read from ADMIN_PASSWORD unless -password is given, so it
+// does not have to appear in the shell history.
+func createAdmin(args []string) {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := flags.String("email", "", "admin email address")
+	username := flags.String("username", "", "admin username")
+	fullName := flags.String("full-name", "", "admin full name")
+	password := flags.String("password", os.Getenv("ADMIN_PASSWORD"), "admin password (default $ADMIN_PASSWORD)")
+	flags.Parse(args)
+
+	if *email == "" || *username == "" || *fullName == "" || *password == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if !config.IsValidEmail(*email) {
+		log.Fatalf("Invalid email format: %s", *email)
+	}
+
+	hashedPassword, err := config.HashPassword(*password)
+	if err != nil {
+		log.Fatalf("Failed to hash password: %v", err)
+	}
+
+	db.ConnectDB()
+	defer db.CloseDB()
+
+	admin, err := server.NewUserService(db.DB).CreateAdmin(strings.TrimSpace(*fullName), *email, *username, hashedPassword)
+	if err != nil {
+		log.Fatalf("Failed to create admin: %v", err)
+	}
+	log.Printf("Created admin %s with ID %d", admin.Username, admin.ID)
+}
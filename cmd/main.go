@@ -3,10 +3,16 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"tender-backend/internal/pkg/config"
 	"tender-backend/internal/http"
 	db "tender-backend/internal/usecase/postgres"
 	"tender-backend/internal/http/handlers"
+	"tender-backend/internal/http/token"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/internal/usecase/file_storage"
+	"tender-backend/internal/usecase/mailer"
+	"tender-backend/internal/usecase/scheduler"
 
 	"github.com/redis/go-redis/v9" // Correct Redis import for v9
 )
@@ -17,6 +23,22 @@ func main() {
 	// Load configuration
 	config.LoadConfig()
 
+	// Subcommands run instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		createAdmin(os.Args[2:])
+		return
+	}
+
+	// Load the keys that sign and verify tokens
+	if err := token.LoadKeys(config.GlobalConfig.Auth.KeysDir, config.GlobalConfig.Auth.SigningKeyID); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
+	// Load the authorization rules
+	if err := policy.Load(config.GlobalConfig.Auth.PolicyFile); err != nil {
+		log.Fatalf("Failed to load authorization policy: %v", err)
+	}
+
 	// Initialize database
 	db.ConnectDB()
 	defer db.CloseDB()
@@ -25,12 +47,33 @@ func main() {
 	InitRedis()
 	defer redisClient.Close()
 
+	// Initialize attachment storage
+	storage, err := file_storage.NewStorage(config.GlobalConfig.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+
+	// Initialize the mailer
+	mail, err := mailer.NewMailer(config.GlobalConfig.Mail)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
 	// Initialize HTTP handlers
-	h := handlers.NewHttpHandler(db.DB, redisClient)
+	h := handlers.NewHttpHandler(db.DB, redisClient, storage, mail)
+
+	// Start the background job that closes tenders after their deadline
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tenderCloser := scheduler.NewTenderCloser(h.TenderService, h.Notifications, redisClient, config.GlobalConfig.Scheduler.TenderCloseInterval)
+	go tenderCloser.Start(ctx)
 
 	// Create and run the router
-	r := http.NewGinRouter(h)
-	err := r.Run(config.GlobalConfig.AppPort)
+	r, err := http.NewGinRouter(h)
+	if err != nil {
+		log.Fatalf("Failed to create router: %v", err)
+	}
+	err = r.Run(config.GlobalConfig.AppPort)
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
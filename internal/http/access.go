@@ -0,0 +1,154 @@
+package http
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"tender-backend/internal/http/middleware"
+	"tender-backend/internal/http/token"
+	"tender-backend/internal/pkg/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authMode says whether a route needs an access token.
+type authMode int
+
+const (
+	public   authMode = iota // No token, e.g. login; the handler does its own checks
+	optional                 // Anonymous callers are let through, a token identifies the caller
+	required                 // A valid access token is needed
+)
+
+// access is a route's entry in the access matrix: whether it needs a token,
+// the action the policy must allow the caller's role, and the roles that may
+// call it under the built-in rules. The services then check the caller's
+// relations with the resource.
+type access struct {
+	auth   authMode
+	action string
+	roles  []string
+}
+
+// Roles of the access matrix; anonymous stands for callers without a token.
+var (
+	everyone    = []string{policy.RoleAnonymous, policy.RoleClient, policy.RoleContractor, policy.RoleAdmin}
+	users       = []string{policy.RoleClient, policy.RoleContractor, policy.RoleAdmin}
+	clients     = []string{policy.RoleClient}
+	contractors = []string{policy.RoleContractor}
+	admins      = []string{policy.RoleAdmin}
+)
+
+// routes registers routes with the middlewares their access entry calls for,
+// and records the entries so the matrix can be verified.
+type routes struct {
+	router   *gin.Engine
+	sessions *token.SessionStore
+	prefix   string
+	matrix   map[string]access
+}
+
+func newRoutes(router *gin.Engine, sessions *token.SessionStore) *routes {
+	return &routes{router: router, sessions: sessions, matrix: make(map[string]access)}
+}
+
+// group returns routes below the prefix sharing the same matrix.
+func (r *routes) group(prefix string) *routes {
+	return &routes{router: r.router, sessions: r.sessions, prefix: r.prefix + prefix, matrix: r.matrix}
+}
+
+func (r *routes) GET(path string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("GET", path, a, handlers)
+}
+
+func (r *routes) POST(path string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("POST", path, a, handlers)
+}
+
+func (r *routes) PUT(path string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("PUT", path, a, handlers)
+}
+
+func (r *routes) DELETE(path string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("DELETE", path, a, handlers)
+}
+
+func (r *routes) handle(method, path string, a access, handlers []gin.HandlerFunc) {
+	var chain []gin.HandlerFunc
+	switch a.auth {
+	case optional:
+		chain = append(chain, middleware.OptionalJWTMiddleware(r.sessions))
+	case required:
+		chain = append(chain, middleware.JWTMiddleware(r.sessions))
+	}
+	if a.action != "" {
+		chain = append(chain, middleware.Authorize(a.action))
+	}
+
+	path = r.prefix + path
+	r.router.Handle(method, path, append(chain, handlers...)...)
+	r.matrix[method+" "+path] = a
+}
+
+// verify checks the access matrix against the router and the built-in rules:
+// every route is in the matrix, every route behind a token names an action,
+// and the rules let exactly the listed roles through. A route added without
+// thinking about who may call it, or a rule change that silently opens or
+// closes a route, stops the server from starting.
+func (r *routes) verify(exempt ...string) error {
+	for _, route := range r.router.Routes() {
+		if slices.ContainsFunc(exempt, func(prefix string) bool { return strings.HasPrefix(route.Path, prefix) }) {
+			continue
+		}
+		if _, ok := r.matrix[route.Method+" "+route.Path]; !ok {
+			return fmt.Errorf("route %s %s is missing from the access matrix", route.Method, route.Path)
+		}
+	}
+
+	for route, a := range r.matrix {
+		if a.auth != public && a.action == "" {
+			return fmt.Errorf("route %s needs a token but names no action", route)
+		}
+	}
+	if differences := r.differences(policy.Default()); len(differences) > 0 {
+		return fmt.Errorf("route %s, the access matrix does not", differences[0])
+	}
+	return nil
+}
+
+// differences lists the routes on which the rules let other roles through
+// than the access matrix, e.g. "GET /api/tenders/search: the rules deny
+// anonymous callers", sorted by route.
+func (r *routes) differences(rules *policy.Policy) []string {
+	var differences []string
+	for route, a := range r.matrix {
+		for _, role := range policy.Roles() {
+			allowed := a.allows(rules, role)
+			if allowed != slices.Contains(a.roles, role) {
+				differences = append(differences, fmt.Sprintf("%s: the rules %s %s callers", route, verb(allowed), role))
+			}
+		}
+	}
+	slices.Sort(differences)
+	return differences
+}
+
+// allows reports whether a caller with the role gets past the route's
+// middlewares under the rules.
+func (a access) allows(rules *policy.Policy, role string) bool {
+	subject := policy.Subject{UserID: 1, Role: role}
+	if role == policy.RoleAnonymous {
+		if a.auth == required {
+			return false
+		}
+		subject = policy.Subject{}
+	}
+	return a.action == "" || rules.Allowed(subject, a.action, nil)
+}
+
+func verb(allowed bool) string {
+	if allowed {
+		return "allow"
+	}
+	return "deny"
+}
@@ -0,0 +1,500 @@
+package http
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tender-backend/internal/http/handlers"
+	"tender-backend/internal/http/token"
+	"tender-backend/internal/pkg/config"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/internal/usecase/file_storage"
+	"tender-backend/internal/usecase/mailer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// accessMatrix is who may call each route: the roles the access layer (the
+// token and the policy's role check) lets through. It is kept by hand, apart
+// from the matrix in router.go, so that a change to either shows up here.
+var accessMatrix = map[string][]string{
+	"POST /login":                    everyone,
+	"POST /login/mfa":                everyone,
+	"POST /register":                 everyone,
+	"POST /auth/refresh":             everyone,
+	"POST /auth/logout":              users,
+	"GET /.well-known/jwks.json":     everyone,
+	"POST /email/verify":             everyone,
+	"POST /email/verify/resend":      users,
+	"POST /password/forgot":          everyone,
+	"POST /password/reset":           everyone,
+	"GET /users/:user_id":            everyone,
+	"PUT /users":                     users,
+	"PUT /users/password":            users,
+	"POST /users/mfa/totp":           users,
+	"POST /users/mfa/totp/confirm":   users,
+	"POST /users/mfa/totp/disable":   users,
+	"POST /users/mfa/recovery-codes": users,
+	"DELETE /users":                  users,
+
+	"GET /api/client/tenders/:tender_id":                               everyone,
+	"GET /api/client/tenders":                                          everyone,
+	"GET /api/client/tenders/:tender_id/versions":                      everyone,
+	"GET /api/client/tenders/:tender_id/versions/:version":             everyone,
+	"GET /api/client/tenders/:tender_id/criteria":                      everyone,
+	"GET /api/tenders/search":                                          everyone,
+	"POST /api/client/tenders":                                         clients,
+	"PUT /api/client/tenders/:tender_id":                               clients,
+	"DELETE /api/client/tenders/:tender_id":                            clients,
+	"GET /api/client/tenders/:tender_id/history":                       clients,
+	"POST /api/client/tenders/:tender_id/amendments":                   clients,
+	"POST /api/client/tenders/:tender_id/questions/:id/answer":         clients,
+	"PUT /api/client/tenders/:tender_id/criteria":                      clients,
+	"POST /api/client/tenders/:tender_id/invitations":                  clients,
+	"GET /api/client/tenders/:tender_id/invitations":                   clients,
+	"DELETE /api/client/tenders/:tender_id/invitations/:contractor_id": clients,
+	"GET /api/client/tenders/:tender_id/awards":                        clients,
+	"GET /api/client/tenders/:tender_id/appeals":                       clients,
+	"POST /api/client/tenders/:tender_id/appeals/:appeal_id/resolve":   clients,
+	"POST /api/client/tenders/:tender_id/award/:bid_id":                clients,
+	"POST /api/client/tenders/:tender_id/award/cancel":                 clients,
+	"POST /api/client/tenders/:tender_id/lots/:lot_id/award/:bid_id":   clients,
+	"GET /api/client/tenders/:tender_id/bids":                          clients,
+	"GET /api/client/tenders/:tender_id/bids/ranking":                  clients,
+	"POST /api/client/tenders/:tender_id/bids/:bid_id/scores":          clients,
+	"GET /api/client/tenders/:tender_id/bids/:bid_id/revisions":        clients,
+	"POST /api/client/tenders/:tender_id/attachments":                  clients,
+	"POST /api/contractor/bids/:bid_id/attachments":                    contractors,
+	"GET /api/tenders/:tender_id/attachments":                          users,
+	"GET /api/bids/:bid_id/attachments":                                users,
+	"GET /api/attachments/:attachment_id":                              users,
+
+	"GET /api/contractor/tenders/:tender_id/bid/:bid_id": {policy.RoleClient, policy.RoleContractor},
+	"POST /api/contractor/tenders/:tender_id/bid":        contractors,
+	"GET /api/contractor/tenders/:tender_id/questions":   users,
+	"POST /api/contractor/tenders/:tender_id/questions":  contractors,
+	"GET /api/contractor/tenders/:tender_id/appeals":     contractors,
+	"POST /api/contractor/tenders/:tender_id/appeals":    contractors,
+	"GET /api/contractor/tenders/:tender_id/auction":     contractors,
+	"GET /api/contractor/bids":                           contractors,
+	"POST /api/contractor/bids/:bid_id/withdraw":         contractors,
+	"PUT /api/contractor/bids/:bid_id":                   contractors,
+	"GET /api/contractor/bids/:bid_id/revisions":         contractors,
+
+	"POST /api/organizations":                                      users,
+	"GET /api/organizations":                                       users,
+	"GET /api/organizations/invitations":                           users,
+	"POST /api/organizations/invitations/:invitation_id/accept":    users,
+	"POST /api/organizations/invitations/:invitation_id/decline":   users,
+	"GET /api/organizations/:org_id":                               users,
+	"POST /api/organizations/:org_id/invitations":                  users,
+	"GET /api/organizations/:org_id/invitations":                   users,
+	"DELETE /api/organizations/:org_id/invitations/:invitation_id": users,
+	"PUT /api/organizations/:org_id/members/:user_id":              users,
+	"DELETE /api/organizations/:org_id/members/:user_id":           users,
+
+	"GET /api/admin/users":                                          admins,
+	"GET /api/admin/users/:user_id":                                 admins,
+	"POST /api/admin/users/:user_id/suspend":                        admins,
+	"POST /api/admin/users/:user_id/reactivate":                     admins,
+	"POST /api/admin/tenders/:tender_id/close":                      admins,
+	"DELETE /api/admin/tenders/:tender_id":                          admins,
+	"POST /api/admin/tenders/:tender_id/appeals/:appeal_id/resolve": admins,
+	"GET /api/admin/bids/:bid_id":                                   admins,
+}
+
+// The responses of the access layer. Anything else means the request got
+// through to the route's own middlewares and handler.
+var denials = []string{
+	`{"message":"Missing token"}`,
+	`{"error":"unauthorized"}`,
+	`{"error":"You are not allowed to access this resource"}`,
+}
+
+// testUsers are the callers with a token, by role.
+var testUsers = map[string]int64{
+	policy.RoleClient:     1,
+	policy.RoleContractor: 2,
+	policy.RoleAdmin:      3,
+}
+
+// TestAccessMatrix sends a request to every route as every kind of caller and
+// checks that exactly the callers of the access matrix get through. The
+// handlers run against a database and Redis that fail every query, so the
+// requests that get through end in an error of the handler.
+func TestAccessMatrix(t *testing.T) {
+	router := newTestRouter(t)
+
+	var routes []string
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/swagger/") {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+	}
+	for route := range accessMatrix {
+		if !slices.Contains(routes, route) {
+			t.Errorf("route %s is not served", route)
+		}
+	}
+
+	for _, route := range routes {
+		allowed, ok := accessMatrix[route]
+		if !ok {
+			t.Errorf("route %s is missing from the test's access matrix", route)
+			continue
+		}
+
+		method, path, _ := strings.Cut(route, " ")
+		path = pathParam.ReplaceAllString(path, "1")
+		for _, role := range policy.Roles() {
+			t.Run(fmt.Sprintf("%s as %s", route, role), func(t *testing.T) {
+				want := slices.Contains(allowed, role)
+				w := serve(t, router, method, path, role)
+				if denied(w) == want {
+					if want {
+						t.Fatalf("got %d %s, want the request to get through", w.Code, w.Body)
+					}
+					t.Fatalf("got %d %s, want the request to be denied", w.Code, w.Body)
+				}
+			})
+		}
+	}
+}
+
+// TestAccessRejectsBadTokens checks that a token only counts while it is
+// valid and its session is alive, including on routes open to anonymous
+// callers.
+func TestAccessRejectsBadTokens(t *testing.T) {
+	router := newTestRouter(t)
+
+	ended, _, err := token.GenerateJWT(testUsers[policy.RoleClient], policy.RoleClient, "ended", false, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := token.GenerateJWT(testUsers[policy.RoleClient], policy.RoleClient, sessionID(testUsers[policy.RoleClient]), false, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A live session of another user
+	stolen, _, err := token.GenerateJWT(testUsers[policy.RoleAdmin], policy.RoleAdmin, sessionID(testUsers[policy.RoleClient]), false, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"expired", expired},
+		{"session ended", ended},
+		{"session of another user", stolen},
+	}
+	for _, tt := range tests {
+		for _, route := range []string{"GET /api/client/tenders", "POST /api/client/tenders", "GET /api/admin/users"} {
+			t.Run(tt.name+" on "+route, func(t *testing.T) {
+				method, path, _ := strings.Cut(route, " ")
+				req := httptest.NewRequest(method, path, nil)
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != http.StatusUnauthorized {
+					t.Fatalf("got %d %s, want 401", w.Code, w.Body)
+				}
+			})
+		}
+	}
+}
+
+// TestAccessMatrixDifferences checks how rules that differ from the built-in
+// ones, e.g. from POLICY_FILE, are reported.
+func TestAccessMatrixDifferences(t *testing.T) {
+	r := newRoutes(gin.New(), nil)
+	r.GET("/tenders", access{optional, policy.ActionTenderRead, everyone}, noop)
+	r.POST("/tenders", access{required, policy.ActionTenderCreate, clients}, noop)
+	r.GET("/admin/users", access{required, policy.ActionAdminUsers, admins}, noop)
+	r.POST("/login", access{public, "", everyone}, noop)
+
+	if differences := r.differences(policy.Default()); len(differences) != 0 {
+		t.Fatalf("the built-in rules differ: %v", differences)
+	}
+
+	rules, err := policy.Parse([]byte(`{"rules": [
+		{"action": "tender:read", "roles": ["*"]},
+		{"action": "tender:create", "roles": ["client", "contractor"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /admin/users: the rules deny admin callers",
+		"GET /tenders: the rules deny anonymous callers",
+		"POST /tenders: the rules allow contractor callers",
+	}
+	if got := r.differences(rules); !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		add     func(r *routes)
+		wantErr string
+	}{
+		{
+			name: "complete matrix",
+			add: func(r *routes) {
+				r.GET("/tenders", access{optional, policy.ActionTenderRead, everyone}, noop)
+				r.POST("/login", access{public, "", everyone}, noop)
+			},
+		},
+		{
+			name: "route outside the matrix",
+			add: func(r *routes) {
+				r.router.GET("/hidden", noop)
+			},
+			wantErr: "route GET /hidden is missing from the access matrix",
+		},
+		{
+			name: "token without an action",
+			add: func(r *routes) {
+				r.GET("/profile", access{required, "", users}, noop)
+			},
+			wantErr: "route GET /profile needs a token but names no action",
+		},
+		{
+			name: "matrix lists a role the rules deny",
+			add: func(r *routes) {
+				r.POST("/tenders", access{required, policy.ActionTenderCreate, users}, noop)
+			},
+			wantErr: "route POST /tenders: the rules deny admin callers, the access matrix does not",
+		},
+		{
+			name: "rules allow a role the matrix does not list",
+			add: func(r *routes) {
+				r.GET("/tenders", access{optional, policy.ActionTenderRead, users}, noop)
+			},
+			wantErr: "route GET /tenders: the rules allow anonymous callers, the access matrix does not",
+		},
+		{
+			name: "required token shuts anonymous callers out",
+			add: func(r *routes) {
+				r.GET("/tenders", access{required, policy.ActionTenderRead, everyone}, noop)
+			},
+			wantErr: "route GET /tenders: the rules deny anonymous callers, the access matrix does not",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRoutes(gin.New(), nil)
+			r.router.GET("/swagger/*any", noop)
+			tt.add(r)
+
+			err := r.verify("/swagger/")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var pathParam = regexp.MustCompile(`:[a-z_]+`)
+
+func noop(c *gin.Context) {}
+
+// serve sends a request as a caller with the role; anonymous callers send no
+// token.
+func serve(t *testing.T, router *gin.Engine, method, path, role string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	if userID, ok := testUsers[role]; ok {
+		accessToken, _, err := token.GenerateJWT(userID, role, sessionID(userID), false, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func denied(w *httptest.ResponseRecorder) bool {
+	if w.Code != http.StatusUnauthorized && w.Code != http.StatusForbidden {
+		return false
+	}
+	return slices.Contains(denials, w.Body.String())
+}
+
+func sessionID(userID int64) string {
+	return "session-" + strconv.FormatInt(userID, 10)
+}
+
+// newTestRouter builds the router with a fresh signing key, a database that
+// fails every query and a Redis that only knows the sessions of testUsers.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	config.GlobalConfig = &config.Config{}
+	config.GlobalConfig.Auth.AccessTokenTTL = time.Hour
+	loadTestKey(t)
+
+	sessions := map[string]string{}
+	for _, userID := range testUsers {
+		sessions["session_"+sessionID(userID)] = strconv.FormatInt(userID, 10)
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+	redisClient.AddHook(redisStub{values: sessions})
+	t.Cleanup(func() { redisClient.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(failingConnector{})}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := file_storage.NewLocalStorage(t.TempDir())
+	h := handlers.NewHttpHandler(db, redisClient, storage, mailer.NewLogMailer())
+
+	router, err := NewGinRouter(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+// loadTestKey loads a new Ed25519 signing key.
+func loadTestKey(t *testing.T) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, "test.pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.LoadKeys(dir, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var errUnavailable = errors.New("unavailable in tests")
+
+// redisStub answers GET and EXISTS from values without a server; every other
+// command fails.
+type redisStub struct {
+	values map[string]string
+}
+
+func (s redisStub) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errUnavailable
+	}
+}
+
+func (s redisStub) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		s.answer(cmd)
+		return cmd.Err()
+	}
+}
+
+func (s redisStub) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		var err error
+		for _, cmd := range cmds {
+			s.answer(cmd)
+			if cmd.Err() != nil && err == nil {
+				err = cmd.Err()
+			}
+		}
+		return err
+	}
+}
+
+func (s redisStub) answer(cmd redis.Cmder) {
+	args := cmd.Args()
+	switch c := cmd.(type) {
+	case *redis.StringCmd:
+		if cmd.Name() == "get" {
+			if value, ok := s.values[fmt.Sprint(args[1])]; ok {
+				c.SetVal(value)
+			} else {
+				c.SetErr(redis.Nil)
+			}
+			return
+		}
+	case *redis.IntCmd:
+		if cmd.Name() == "exists" {
+			var found int64
+			for _, key := range args[1:] {
+				if _, ok := s.values[fmt.Sprint(key)]; ok {
+					found++
+				}
+			}
+			c.SetVal(found)
+			return
+		}
+	}
+	cmd.SetErr(errUnavailable)
+}
+
+// failingConnector is a database whose every query fails.
+type failingConnector struct{}
+
+func (failingConnector) Connect(context.Context) (driver.Conn, error) { return nil, errUnavailable }
+func (failingConnector) Driver() driver.Driver                        { return failingDriver{} }
+
+type failingDriver struct{}
+
+func (failingDriver) Open(string) (driver.Conn, error) { return nil, errUnavailable }
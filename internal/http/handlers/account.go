@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"tender-backend/internal/pkg/config"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirms the user's email address with the token from the verification email. Each token works once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param token body request_model.VerifyEmailReq true "Verification token"
+// @Success 200 {object} string "Email verified"
+// @Failure 400 {object} string "The link is invalid or has expired"
+// @Router /email/verify [post]
+func (h *HTTPHandler) VerifyEmail(c *gin.Context) {
+	var req request_model.VerifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.AccountService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Sends a new verification link to the user's email address. Earlier links stop working.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} string "Verification email sent"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 409 {object} string "Email is already verified"
+// @Security BearerAuth
+// @Router /email/verify/resend [post]
+func (h *HTTPHandler) ResendVerification(c *gin.Context) {
+	user, err := h.UserService.GetUserByID(c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.AccountService.SendVerification(c.Request.Context(), user); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a password reset link to the address if an account is registered with it. The response is the same either way.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param email body request_model.ForgotPasswordReq true "Account email"
+// @Success 200 {object} string "Reset link sent"
+// @Failure 400 {object} string "Invalid email format"
+// @Router /password/forgot [post]
+func (h *HTTPHandler) ForgotPassword(c *gin.Context) {
+	var req request_model.ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !config.IsValidEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		return
+	}
+
+	if err := h.AccountService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a reset link has been sent to it"})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Sets a new password with the token from the reset email and revokes every token issued to the user. Each reset token works once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param reset body request_model.ResetPasswordReq true "Reset token and new password"
+// @Success 200 {object} string "Password reset"
+// @Failure 400 {object} string "The link is invalid or has expired"
+// @Router /password/reset [post]
+func (h *HTTPHandler) ResetPassword(c *gin.Context) {
+	var req request_model.ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New password cannot be empty"})
+		return
+	}
+
+	hashedPassword, err := config.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+
+	userID, err := h.AccountService.ResetPassword(c.Request.Context(), req.Token, hashedPassword)
+	if err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	if err := h.Sessions.RevokeAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password reset but tokens could not be revoked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset"})
+}
+
+func respondAccountError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "Email is already verified"):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	server "tender-backend/internal/storage/repo"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsers godoc
+// @Security BearerAuth
+// @Summary List users
+// @Description Lists users newest first. The search matches name, email and username.
+// @Tags Admin
+// @Produce json
+// @Param search query string false "Search term"
+// @Param role query string false "Role: client, contractor or admin"
+// @Param status query string false "Status: active or suspended"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param offset query int false "Page offset" default(0)
+// @Success 200 {object} response_model.UserListRes
+// @Failure 400 {object} string "Invalid filter"
+// @Failure 403 {object} string "You are not allowed to access this resource"
+// @Router /api/admin/users [get]
+func (h *HTTPHandler) ListUsers(ctx *gin.Context) {
+	var filter request_model.UserFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	users, err := h.UserService.ListUsers(&filter)
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, users)
+}
+
+// GetUserForAdmin godoc
+// @Security BearerAuth
+// @Summary Get a user
+// @Description Returns any user including their account status
+// @Tags Admin
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} response_model.AdminUserRes
+// @Failure 403 {object} string "You are not allowed to access this resource"
+// @Failure 404 {object} string "User not found"
+// @Router /api/admin/users/{user_id} [get]
+func (h *HTTPHandler) GetUserForAdmin(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.UserService.GetUserForAdmin(int64(userID))
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// SuspendUser godoc
+// @Security BearerAuth
+// @Summary Suspend a user
+// @Description Stops a user from logging in and revokes all of their tokens. Admins cannot be suspended.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param suspension body request_model.AdminActionReq true "Suspension reason"
+// @Success 200 {object} response_model.AdminUserRes
+// @Failure 400 {object} string "A suspension reason is required"
+// @Failure 404 {object} string "User not found"
+// @Failure 409 {object} string "User is already suspended"
+// @Router /api/admin/users/{user_id}/suspend [post]
+func (h *HTTPHandler) SuspendUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req request_model.AdminActionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	user, err := h.UserService.SuspendUser(int64(userID), req.Reason)
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	if err := h.Sessions.RevokeAll(ctx.Request.Context(), user.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "User suspended but tokens could not be revoked"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// ReactivateUser godoc
+// @Security BearerAuth
+// @Summary Reactivate a user
+// @Description Lifts the suspension of a user so they can log in again
+// @Tags Admin
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} response_model.AdminUserRes
+// @Failure 404 {object} string "User not found"
+// @Failure 409 {object} string "User is not suspended"
+// @Router /api/admin/users/{user_id}/reactivate [post]
+func (h *HTTPHandler) ReactivateUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.UserService.ReactivateUser(int64(userID))
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// ForceCloseTender godoc
+// @Security BearerAuth
+// @Summary Force-close a tender
+// @Description Cancels a tender that is not closed for good yet, together with a pending award. The client and the bidders are notified with the reason.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param closure body request_model.AdminActionReq true "Reason"
+// @Success 200 {object} model.Tender
+// @Failure 400 {object} string "A reason is required"
+// @Failure 404 {object} string "Tender not found"
+// @Failure 409 {object} string "Invalid status transition"
+// @Router /api/admin/tenders/{tender_id}/close [post]
+func (h *HTTPHandler) ForceCloseTender(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req request_model.AdminActionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	tender, err := h.TenderService.ForceCloseTender(int64(tenderID), ctx.GetInt64("user_id"), req.Reason)
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tender)
+}
+
+// RemoveTender godoc
+// @Security BearerAuth
+// @Summary Remove a tender
+// @Description Deletes any tender, e.g. one that breaks the platform rules. The client is notified with the reason.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param removal body request_model.AdminActionReq true "Reason"
+// @Success 200 {object} string "Tender removed"
+// @Failure 400 {object} string "A reason is required"
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/admin/tenders/{tender_id} [delete]
+func (h *HTTPHandler) RemoveTender(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req request_model.AdminActionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.TenderService.RemoveTender(int64(tenderID), req.Reason); err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Tender removed"})
+}
+
+// AdminResolveAppeal godoc
+// @Security BearerAuth
+// @Summary Resolve an appeal on any tender
+// @Description Upholds or dismisses an open appeal in place of the client. Upholding it cancels the pending award and sends the tender back to evaluation.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param appeal_id path int true "Appeal ID"
+// @Param decision body request_model.ResolveAppealReq true "Decision"
+// @Success 200 {object} model.TenderAppeal
+// @Failure 400 {object} string "Invalid decision"
+// @Failure 404 {object} string "Appeal not found"
+// @Failure 409 {object} string "Appeal already resolved"
+// @Router /api/admin/tenders/{tender_id}/appeals/{appeal_id}/resolve [post]
+func (h *HTTPHandler) AdminResolveAppeal(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	appealID, err := strconv.Atoi(ctx.Param("appeal_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appeal ID"})
+		return
+	}
+
+	var req request_model.ResolveAppealReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	appeal, err := h.TenderService.AdminResolveAppeal(int64(tenderID), int64(appealID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondAwardError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appeal)
+}
+
+// GetBidForAdmin godoc
+// @Security BearerAuth
+// @Summary Get any bid
+// @Description Returns a bid with its lots. Bids of a sealed tender are redacted until the bid opening.
+// @Tags Admin
+// @Produce json
+// @Param bid_id path int true "Bid ID"
+// @Success 200 {object} model.Bid
+// @Failure 404 {object} string "Bid not found"
+// @Router /api/admin/bids/{bid_id} [get]
+func (h *HTTPHandler) GetBidForAdmin(ctx *gin.Context) {
+	bidID, err := strconv.Atoi(ctx.Param("bid_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	bid, err := h.BidService.GetBidForAdmin(int64(bidID))
+	if err != nil {
+		respondAdminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bid)
+}
+
+func respondAdminError(ctx *gin.Context, err error) {
+	var transitionErr *server.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "Admins cannot"):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "User is"), strings.HasSuffix(err.Error(), "was modified concurrently"):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
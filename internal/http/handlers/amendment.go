@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AmendTender godoc
+// @Security BearerAuth
+// @Summary Amend a tender
+// @Description Changes the title, description, budget or deadline of a draft or open tender and creates a new tender version. Contractors who already bid are notified.
+// @Tags Tender
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param amendment body request_model.AmendTenderReq true "Amended fields"
+// @Success 200 {object} model.Tender
+// @Failure 400 {object} string "Invalid amendment"
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Router /api/client/tenders/{tender_id}/amendments [post]
+func (h *HTTPHandler) AmendTender(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	req := request_model.AmendTenderReq{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input. Please check your request format."})
+		return
+	}
+
+	tender, err := h.TenderService.AmendTender(int64(tenderID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid input"):
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		case strings.HasPrefix(err.Error(), "only draft or open"):
+			ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "Tender not found or access denied"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tender)
+}
+
+// GetTenderVersions godoc
+// @Summary Get tender versions
+// @Description Lists every version of a tender created by amendments, oldest first
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.TenderVersion
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/client/tenders/{tender_id}/versions [get]
+func (h *HTTPHandler) GetTenderVersions(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	if !h.tenderVisible(ctx, int64(tenderID)) {
+		return
+	}
+
+	versions, err := h.TenderService.GetTenderVersions(int64(tenderID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Tender not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// GetTenderVersion godoc
+// @Summary Get a tender version
+// @Description Gets one version of a tender
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} model.TenderVersion
+// @Failure 404 {object} string "Tender version not found"
+// @Router /api/client/tenders/{tender_id}/versions/{version} [get]
+func (h *HTTPHandler) GetTenderVersion(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	if !h.tenderVisible(ctx, int64(tenderID)) {
+		return
+	}
+
+	tenderVersion, err := h.TenderService.GetTenderVersion(int64(tenderID), version)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tenderVersion)
+}
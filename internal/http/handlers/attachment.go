@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadTenderAttachment godoc
+// @Summary Upload a tender document
+// @Description Uploads a technical specification or drawing for a tender. Only the tender owner can upload.
+// @Tags Attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param file formData file true "Document"
+// @Success 201 {object} model.Attachment
+// @Failure 400 {object} string "Invalid file"
+// @Failure 413 {object} string "File too large"
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Security BearerAuth
+// @Router /api/client/tenders/{tender_id}/attachments [post]
+func (h *HTTPHandler) UploadTenderAttachment(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	file, ok := h.uploadedFile(c)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.AttachmentService.UploadTenderAttachment(int64(tenderID), c.GetInt64("user_id"), file)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// UploadBidAttachment godoc
+// @Summary Upload a bid proposal
+// @Description Uploads a proposal document for a bid while the tender is open. Only the bidder can upload.
+// @Tags Attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param bid_id path int true "Bid ID"
+// @Param file formData file true "Document"
+// @Success 201 {object} model.Attachment
+// @Failure 400 {object} string "Invalid file"
+// @Failure 413 {object} string "File too large"
+// @Failure 404 {object} string "Bid not found or access denied"
+// @Security BearerAuth
+// @Router /api/contractor/bids/{bid_id}/attachments [post]
+func (h *HTTPHandler) UploadBidAttachment(c *gin.Context) {
+	bidID, err := strconv.Atoi(c.Param("bid_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	file, ok := h.uploadedFile(c)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.AttachmentService.UploadBidAttachment(int64(bidID), c.GetInt64("user_id"), file)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetTenderAttachments godoc
+// @Summary List tender documents
+// @Description Lists the documents of a tender. Available to the tender owner and its bidders.
+// @Tags Attachment
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.Attachment
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Security BearerAuth
+// @Router /api/tenders/{tender_id}/attachments [get]
+func (h *HTTPHandler) GetTenderAttachments(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	attachments, err := h.AttachmentService.ListTenderAttachments(int64(tenderID), c.GetInt64("user_id"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// GetBidAttachments godoc
+// @Summary List bid documents
+// @Description Lists the proposal documents of a bid. Available to the bidder and the tender owner; for a sealed tender, to the owner only after the bid opening.
+// @Tags Attachment
+// @Produce json
+// @Param bid_id path int true "Bid ID"
+// @Success 200 {object} []model.Attachment
+// @Failure 403 {object} string "Bid documents are sealed until the bid opening"
+// @Failure 404 {object} string "Bid not found or access denied"
+// @Security BearerAuth
+// @Router /api/bids/{bid_id}/attachments [get]
+func (h *HTTPHandler) GetBidAttachments(c *gin.Context) {
+	bidID, err := strconv.Atoi(c.Param("bid_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	attachments, err := h.AttachmentService.ListBidAttachments(int64(bidID), c.GetInt64("user_id"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment godoc
+// @Summary Download a document
+// @Description Downloads a tender or bid document. The SHA-256 checksum is sent in the X-Checksum-SHA256 header.
+// @Tags Attachment
+// @Produce octet-stream
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 403 {object} string "Bid documents are sealed until the bid opening"
+// @Failure 404 {object} string "Attachment not found or access denied"
+// @Security BearerAuth
+// @Router /api/attachments/{attachment_id} [get]
+func (h *HTTPHandler) DownloadAttachment(c *gin.Context) {
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	attachment, content, err := h.AttachmentService.OpenAttachment(int64(attachmentID), c.GetInt64("user_id"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Header("X-Checksum-SHA256", attachment.SHA256)
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, io.Reader(content), nil)
+}
+
+// multipartOverhead leaves room for the boundaries and part headers of the
+// form on top of the file itself.
+const multipartOverhead = 1 << 20
+
+// uploadedFile returns the "file" part of a multipart upload. The body is
+// capped before it is parsed, so an oversized upload is neither buffered in
+// memory nor spooled to disk.
+func (h *HTTPHandler) uploadedFile(c *gin.Context) (*multipart.FileHeader, bool) {
+	maxSize := h.AttachmentService.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the limit of %d MB", maxSize>>20)})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return nil, false
+	}
+	return file, true
+}
+
+func respondAttachmentError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not open"):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "Bid documents are sealed"):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	default:
+		log.Printf("Attachment error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"tender-backend/internal/pkg/config"
+	"tender-backend/internal/usecase/web_socket"
+	request_model "tender-backend/model/request"
+	response_model "tender-backend/model/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: checkAuctionOrigin,
+}
+
+// checkAuctionOrigin lets browsers join an auction from the API's own origin
+// and from the origins of AUCTION_ALLOWED_ORIGINS. Requests without an Origin
+// header do not come from a browser page and are let through.
+func checkAuctionOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if slices.Contains(config.GlobalConfig.Tender.AuctionOrigins, origin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// JoinAuction godoc
+// @Summary Join a live reverse auction
+// @Description Upgrades the connection to a websocket. The server sends the current auction state on join and after every accepted bid; the contractor sends bids as JSON in the CreateBidReq format.
+// @Tags Bid
+// @Param tender_id path string true "Tender ID"
+// @Success 101 {object} response_model.AuctionEvent "Switching protocols"
+// @Failure 400 {object} string "Invalid tender ID"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Origin not allowed"
+// @Failure 404 {object} string "Tender not found or not an auction"
+// @Security BearerAuth
+// @Router /api/contractor/tenders/{tender_id}/auction [GET]
+func (h *HTTPHandler) JoinAuction(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	if !h.tenderVisible(c, int64(tenderID)) {
+		return
+	}
+
+	state, err := h.BidService.GetAuctionState(int64(tenderID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Auction: failed to upgrade connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	contractorID := c.GetInt64("user_id")
+	room := web_socket.JoinAuction(int64(tenderID), contractorID, conn)
+	defer room.Leave(contractorID, conn)
+
+	_ = room.Send(contractorID, auctionEvent("state", state, ""))
+
+	for {
+		var req request_model.CreateBidReq
+		if err := conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("Auction: read error for contractor %d: %v", contractorID, err)
+			}
+			return
+		}
+
+		_, state, err := h.BidService.PlaceAuctionBid(&req, int64(tenderID), contractorID)
+		if err != nil {
+			_ = room.Send(contractorID, auctionEvent("error", nil, err.Error()))
+			continue
+		}
+
+		room.Broadcast(auctionEvent("bid", state, ""))
+	}
+}
+
+func auctionEvent(eventType string, state *response_model.AuctionState, message string) []byte {
+	event, _ := json.Marshal(response_model.AuctionEvent{
+		Type:    eventType,
+		State:   state,
+		Message: message,
+	})
+	return event
+}
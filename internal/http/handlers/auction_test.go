@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"tender-backend/internal/pkg/config"
+)
+
+func TestCheckAuctionOrigin(t *testing.T) {
+	saved := config.GlobalConfig
+	t.Cleanup(func() { config.GlobalConfig = saved })
+	config.GlobalConfig = &config.Config{
+		Tender: config.TenderConfig{AuctionOrigins: []string{"https://app.example.com"}},
+	}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "", true},
+		{"same origin", "https://api.example.com", true},
+		{"same origin in other case", "https://API.example.com", true},
+		{"allowed origin", "https://app.example.com", true},
+		{"other site", "https://evil.example.com", false},
+		{"allowed host on another scheme", "http://app.example.com", false},
+		{"allowed host on another port", "https://app.example.com:8443", false},
+		{"malformed origin", "://", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "https://api.example.com/api/contractor/tenders/1/auction", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := checkAuctionOrigin(r); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"tender-backend/internal/http/token"
@@ -15,7 +16,7 @@ import (
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with email, username, and password
+// @Description Register a new user with email, username, and password. A verification link is emailed to the user; creating tenders and bidding need a verified email.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -63,24 +64,24 @@ func (h *HTTPHandler) Register(c *gin.Context) {
 		return
 	}
 
-	tkn, err := token.GenerateJWT(user.ID, user.Role)
+	// The account works without it, and the user can ask for a new email
+	if err := h.AccountService.SendVerification(c.Request.Context(), user); err != nil {
+		fmt.Printf("Error sending verification email: %v", err)
+	}
+
+	tokens, err := h.Sessions.Start(c.Request.Context(), user.ID, user.Role, false)
 
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	res := response_model.LoginRes{
-		Token: tkn,
-		Role:  user.Role,
-	}
-
-	c.JSON(201, res)
+	c.JSON(201, loginResponse(tokens))
 }
 
 // Login godoc
 // @Summary Login a user
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Users with two-factor authentication get an MFA challenge (response_model.MFAChallengeRes) to complete at /login/mfa instead of tokens.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -88,6 +89,7 @@ func (h *HTTPHandler) Register(c *gin.Context) {
 // @Success 200 {object} response_model.LoginRes "JWT tokens"
 // @Failure 400 {object} string "Invalid request payload"
 // @Failure 401 {object} string "Invalid email or password"
+// @Failure 403 {object} string "Account is suspended"
 // @Router /login [post]
 func (h *HTTPHandler) Login(c *gin.Context) {
 	req := request_model.LoginUserReq{}
@@ -113,17 +115,147 @@ func (h *HTTPHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tkn, err := token.GenerateJWT(user.ID, user.Role)
+	if user.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Account is suspended"})
+		return
+	}
+
+	// With two-factor authentication the tokens are only issued by LoginMFA
+	if user.TOTPEnabledAt != nil {
+		challenge, expiresAt, err := h.MFAService.StartChallenge(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response_model.MFAChallengeRes{
+			MFARequired: true,
+			MFAToken:    challenge,
+			ExpiresAt:   expiresAt.Unix(),
+		})
+		return
+	}
+
+	tokens, err := h.Sessions.Start(c.Request.Context(), user.ID, user.Role, false)
 
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	res := response_model.LoginRes{
-		Token: tkn,
-		Role:  user.Role,
+	c.JSON(http.StatusOK, loginResponse(tokens))
+}
+
+// LoginMFA godoc
+// @Summary Complete a login with two-factor authentication
+// @Description Second login step for users with two-factor authentication: exchanges the MFA token from /login and a TOTP or recovery code for JWT tokens. The challenge is dropped after 5 wrong codes.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param credentials body request_model.LoginMFAReq true "MFA token and code"
+// @Success 200 {object} response_model.LoginRes "JWT tokens"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Invalid authentication code"
+// @Failure 403 {object} string "Account is suspended"
+// @Failure 429 {object} string "Too many wrong authentication codes"
+// @Router /login/mfa [post]
+func (h *HTTPHandler) LoginMFA(c *gin.Context) {
+	var req request_model.LoginMFAReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "mfa_token and code are required"})
+		return
+	}
+
+	user, err := h.MFAService.CompleteChallenge(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	// The account may have been suspended after the password step
+	if user.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Account is suspended"})
+		return
+	}
+
+	tokens, err := h.Sessions.Start(c.Request.Context(), user.ID, user.Role, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, res)
+	c.JSON(http.StatusOK, loginResponse(tokens))
+}
+
+// RefreshToken godoc
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new access and refresh token. Every refresh token can be used once; presenting a used one again ends its session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param token body request_model.RefreshTokenReq true "Refresh token"
+// @Success 200 {object} response_model.LoginRes "JWT tokens"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Invalid or expired refresh token"
+// @Router /auth/refresh [post]
+func (h *HTTPHandler) RefreshToken(c *gin.Context) {
+	var req request_model.RefreshTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "refresh_token is required"})
+		return
+	}
+
+	tokens, err := h.Sessions.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse(tokens))
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revokes the access token and ends its session, so the session's refresh token stops working too
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} string "Logged out"
+// @Failure 401 {object} string "Unauthorized"
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func (h *HTTPHandler) Logout(c *gin.Context) {
+	claims := c.MustGet("claims").(*token.Claims)
+
+	if err := h.Sessions.Logout(c.Request.Context(), claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// GetJWKS godoc
+// @Summary Public keys that verify tokens
+// @Description Publishes the public part of every key that access tokens may be signed with, as a JSON Web Key Set. Tokens name their key in the "kid" header.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} token.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *HTTPHandler) GetJWKS(c *gin.Context) {
+	// Verifiers may cache the keys; a new key is published well before it signs
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, token.PublicKeys())
+}
+
+func loginResponse(tokens *token.TokenPair) response_model.LoginRes {
+	return response_model.LoginRes{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+		Role:         tokens.Role,
+	}
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	server "tender-backend/internal/storage/repo"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelAward godoc
+// @Security BearerAuth
+// @Summary Cancel a pending award
+// @Description Cancels the pending award of a tender during its standstill period and sends the tender back to evaluation so it can be awarded again.
+// @Tags Tender
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param cancellation body request_model.CancelAwardReq true "Cancellation reason"
+// @Success 200 {object} string "Award cancelled"
+// @Failure 400 {object} string "Invalid request"
+// @Failure 404 {object} string "Tender not found"
+// @Failure 409 {object} string "Tender has no pending award"
+// @Router /api/client/tenders/{tender_id}/award/cancel [post]
+func (h *HTTPHandler) CancelAward(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req request_model.CancelAwardReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.TenderService.CancelAward(int64(tenderID), ctx.GetInt64("user_id"), req.Reason); err != nil {
+		respondAwardError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Award cancelled"})
+}
+
+// GetAwards godoc
+// @Security BearerAuth
+// @Summary List the award decisions of a tender
+// @Description Lists every award of the client's tender, including cancelled ones, oldest first
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.TenderAward
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/client/tenders/{tender_id}/awards [get]
+func (h *HTTPHandler) GetAwards(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	awards, err := h.TenderService.GetAwards(int64(tenderID), ctx.GetInt64("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, awards)
+}
+
+// FileAppeal godoc
+// @Security BearerAuth
+// @Summary Appeal a pending award
+// @Description Lets a bidder whose bid was not selected, or an owner or manager of the bidding organization, appeal the award until the standstill period ends. The award cannot become final while an appeal is open.
+// @Tags Tender
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param appeal body request_model.FileAppealReq true "Appeal"
+// @Success 201 {object} model.TenderAppeal
+// @Failure 400 {object} string "Invalid appeal"
+// @Failure 403 {object} string "Only bidders can appeal"
+// @Failure 404 {object} string "Tender not found"
+// @Failure 409 {object} string "Appeals are closed"
+// @Router /api/contractor/tenders/{tender_id}/appeals [post]
+func (h *HTTPHandler) FileAppeal(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req request_model.FileAppealReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	appeal, err := h.TenderService.FileAppeal(int64(tenderID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondAwardError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, appeal)
+}
+
+// GetAppeals godoc
+// @Security BearerAuth
+// @Summary List the appeals of a tender
+// @Description The tender owner sees every appeal, a contractor only their own
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.TenderAppeal
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/client/tenders/{tender_id}/appeals [get]
+// @Router /api/contractor/tenders/{tender_id}/appeals [get]
+func (h *HTTPHandler) GetAppeals(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	appeals, err := h.TenderService.GetAppeals(int64(tenderID), ctx.GetInt64("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appeals)
+}
+
+// ResolveAppeal godoc
+// @Security BearerAuth
+// @Summary Resolve an appeal
+// @Description Upholds or dismisses an open appeal. Upholding it cancels the pending award and sends the tender back to evaluation.
+// @Tags Tender
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param appeal_id path int true "Appeal ID"
+// @Param decision body request_model.ResolveAppealReq true "Decision"
+// @Success 200 {object} model.TenderAppeal
+// @Failure 400 {object} string "Invalid decision"
+// @Failure 404 {object} string "Appeal not found"
+// @Failure 409 {object} string "Appeal already resolved"
+// @Router /api/client/tenders/{tender_id}/appeals/{appeal_id}/resolve [post]
+func (h *HTTPHandler) ResolveAppeal(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	appealID, err := strconv.Atoi(ctx.Param("appeal_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appeal ID"})
+		return
+	}
+
+	var req request_model.ResolveAppealReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	appeal, err := h.TenderService.ResolveAppeal(int64(tenderID), int64(appealID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondAwardError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appeal)
+}
+
+// awardConflicts are the messages of requests that are valid but not allowed
+// in the current state of the award.
+var awardConflicts = []string{
+	"Tender has no pending award",
+	"The standstill period has ended",
+	"The selected bidder cannot appeal",
+	"You already have an open appeal",
+	"Appeal has already been",
+}
+
+func respondAwardError(ctx *gin.Context, err error) {
+	var transitionErr *server.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	for _, conflict := range awardConflicts {
+		if strings.HasPrefix(err.Error(), conflict) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "Only bidders"):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
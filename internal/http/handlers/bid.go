@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	server "tender-backend/internal/storage/repo"
 	request_model "tender-backend/model/request"
 
 	"github.com/gin-gonic/gin"
@@ -10,15 +13,18 @@ import (
 
 // CreateBid godoc
 // @Summary Create a new bid
-// @Description Creates a new bid. Example time: 2024-11-16T15:00:00Z
+// @Description Creates a new bid. A contractor may hold one active bid per tender. Example time: 2024-11-16T15:00:00Z
 // @Tags Bid
 // @Accept json
 // @Produce json
 // @Param tender_id path string true "Tender ID"
+// @Param Idempotency-Key header string false "Replays the original response when the same key is sent again"
 // @Param bid body request_model.CreateBidReq true "Bid creation request"
 // @Success 201 {object} model.Bid "Bid created successfully"
 // @Failure 400 {object} string "Invalid request payload"
 // @Failure 401 {object} string "Unauthorized"
+// @Failure 409 {object} string "Bid submitted after the deadline, an active bid already exists, or the Idempotency-Key is in use"
+// @Failure 422 {object} string "Idempotency-Key reused with a different request"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
 // @Router /api/contractor/tenders/{tender_id}/bid [POST]
@@ -40,6 +46,16 @@ func (h *HTTPHandler) CreateBid(c *gin.Context) {
 
 	createdBid, err := h.BidService.CreateBid(&req, int64(tenderId), contractorId)
 	if err != nil {
+		var lateErr *server.LateBidError
+		if errors.As(err, &lateErr) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+		var dupErr *server.DuplicateBidError
+		if errors.As(err, &dupErr) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error(), "bid_id": dupErr.BidID})
+			return
+		}
 		if err.Error()=="Tender not found"{
 			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
@@ -53,7 +69,7 @@ func (h *HTTPHandler) CreateBid(c *gin.Context) {
 
 // GetBidByID godoc
 // @Summary Get Bid by ID
-// @Description Retrieves a bid by its ID.
+// @Description Retrieves a bid by its ID for its bidder or the tender owner. Bids of a sealed tender are redacted until the bid opening.
 // @Tags Bid
 // @Accept json
 // @Produce json
@@ -79,8 +95,12 @@ func (h *HTTPHandler) GetBid(c *gin.Context) {
 		return
 	}
 
-	bid, err := h.BidService.GetBidByID(int64(bidID), int64(tenderID))
+	bid, err := h.BidService.GetBidByID(int64(bidID), int64(tenderID), c.GetInt64("user_id"))
 	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bid"})
 		return
 	}
@@ -90,7 +110,7 @@ func (h *HTTPHandler) GetBid(c *gin.Context) {
 
 // GetBids godoc
 // @Summary Get all Bids
-// @Description Retrieves all Bids for the authenticated user.
+// @Description Retrieves all Bids of the tender for its owner, including withdrawn ones. Bids of a sealed tender are redacted until the bid opening.
 // @Tags Bid
 // @Accept json
 // @Produce json
@@ -108,7 +128,7 @@ func (h *HTTPHandler) GetBids(c *gin.Context) {
 		return
 	}
 
-	bids, err := h.BidService.GetAllBids(int64(tenderID))
+	bids, err := h.BidService.GetAllBids(int64(tenderID), c.GetInt64("user_id"))
 	if err != nil {
 		c.JSON(404, gin.H{"message": err.Error()})
 		return
@@ -139,20 +159,23 @@ func (h *HTTPHandler) GetContractorBids(c *gin.Context) {
 	c.JSON(http.StatusOK, bids)
 }
 
-// DeleteBid godoc
-// @Summary Delete a Bid
-// @Description Deletes a Bid by its ID.
+// WithdrawBid godoc
+// @Summary Withdraw a Bid
+// @Description Withdraws a pending Bid while its tender is open. The Bid stays visible to the client with the reason and time of withdrawal.
 // @Tags Bid
 // @Accept json
 // @Produce json
 // @Param bid_id path string true "Bid ID"
-// @Success 200 {object} string "Bid deleted successfully"
+// @Param withdrawal body request_model.WithdrawBidReq true "Withdrawal reason"
+// @Success 200 {object} model.Bid "Bid withdrawn successfully"
+// @Failure 400 {object} string "Invalid request payload"
 // @Failure 401 {object} string "Unauthorized"
 // @Failure 404 {object} string "Bid not found"
+// @Failure 409 {object} string "Bid can no longer be withdrawn"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
-// @Router /api/contractor/bids/{bid_id} [DELETE]
-func (h *HTTPHandler) DeleteBid(c *gin.Context) {
+// @Router /api/contractor/bids/{bid_id}/withdraw [POST]
+func (h *HTTPHandler) WithdrawBid(c *gin.Context) {
 	bidIDStr := c.Param("bid_id")
 	bidID, err := strconv.Atoi(bidIDStr)
 	if err != nil {
@@ -160,12 +183,139 @@ func (h *HTTPHandler) DeleteBid(c *gin.Context) {
 		return
 	}
 
-	err = h.BidService.DeleteBid(int64(bidID), c.GetInt64("user_id"))
+	var req request_model.WithdrawBidReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	bid, err := h.BidService.WithdrawBid(int64(bidID), c.GetInt64("user_id"), req.Reason)
 	if err != nil {
-		c.JSON(404, gin.H{"message": err.Error()})
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid input"):
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		case strings.HasPrefix(err.Error(), "Bid not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case strings.HasPrefix(err.Error(), "Bid cannot be withdrawn") || err.Error() == "Tender is not open for bids":
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, bid)
+}
+
+// ReviseBid godoc
+// @Summary Revise a Bid
+// @Description Replaces the terms of a Bid while its tender is open. The previous terms are kept in the revision history.
+// @Tags Bid
+// @Accept json
+// @Produce json
+// @Param bid_id path string true "Bid ID"
+// @Param bid body request_model.CreateBidReq true "Revised bid"
+// @Success 200 {object} model.Bid "Bid revised successfully"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Bid not found"
+// @Failure 409 {object} string "Tender is no longer accepting bids"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /api/contractor/bids/{bid_id} [PUT]
+func (h *HTTPHandler) ReviseBid(c *gin.Context) {
+	bidID, err := strconv.Atoi(c.Param("bid_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	var req request_model.CreateBidReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	bid, err := h.BidService.ReviseBid(int64(bidID), c.GetInt64("user_id"), &req)
+	if err != nil {
+		var lateErr *server.LateBidError
+		switch {
+		case errors.As(err, &lateErr):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		case strings.HasPrefix(err.Error(), "Bid not found"):
+			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		case err.Error() == "Tender is not open for bids" || err.Error() == "Auction bids cannot be revised" || strings.HasPrefix(err.Error(), "Bid cannot be revised"):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Bid deleted successfully"})
+	c.JSON(http.StatusOK, bid)
 }
 
+// GetBidRevisions godoc
+// @Summary Get the revision history of a Bid
+// @Description Lists the previous versions of the authenticated Contractor's Bid, oldest first.
+// @Tags Bid
+// @Produce json
+// @Param bid_id path string true "Bid ID"
+// @Success 200 {object} []model.BidRevision "Bid revisions"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Bid not found"
+// @Security BearerAuth
+// @Router /api/contractor/bids/{bid_id}/revisions [GET]
+func (h *HTTPHandler) GetBidRevisions(c *gin.Context) {
+	bidID, err := strconv.Atoi(c.Param("bid_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	revisions, err := h.BidService.GetBidRevisions(int64(bidID), c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetClientBidRevisions godoc
+// @Summary Get the revision history of a Bid on the client's tender
+// @Description Lists the previous versions of a Bid. Available once the tender deadline has passed and sealed bids have been opened.
+// @Tags Bid
+// @Produce json
+// @Param tender_id path string true "Tender ID"
+// @Param bid_id path string true "Bid ID"
+// @Success 200 {object} []model.BidRevision "Bid revisions"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Bid history is not available yet"
+// @Failure 404 {object} string "Bid not found"
+// @Security BearerAuth
+// @Router /api/client/tenders/{tender_id}/bids/{bid_id}/revisions [GET]
+func (h *HTTPHandler) GetClientBidRevisions(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+	bidID, err := strconv.Atoi(c.Param("bid_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	revisions, err := h.BidService.GetBidRevisionsForClient(int64(tenderID), int64(bidID), c.GetInt64("user_id"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Bid history is available") {
+			c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
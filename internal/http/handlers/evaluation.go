@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetCriteria godoc
+// @Security BearerAuth
+// @Summary Set evaluation criteria
+// @Description Replaces the weighted evaluation criteria of a draft or open tender. Kinds are price, delivery_time and qualitative.
+// @Tags Evaluation
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param criteria body request_model.SetCriteriaReq true "Evaluation criteria"
+// @Success 200 {object} []model.EvaluationCriterion
+// @Failure 400 {object} string "Invalid criteria"
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Router /api/client/tenders/{tender_id}/criteria [put]
+func (h *HTTPHandler) SetCriteria(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	req := request_model.SetCriteriaReq{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input. Please check your request format."})
+		return
+	}
+
+	criteria, err := h.EvaluationService.SetCriteria(int64(tenderID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondEvaluationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, criteria)
+}
+
+// GetCriteria godoc
+// @Summary Get evaluation criteria
+// @Description Gets the weighted evaluation criteria of a tender
+// @Tags Evaluation
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.EvaluationCriterion
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/client/tenders/{tender_id}/criteria [get]
+func (h *HTTPHandler) GetCriteria(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	if !h.tenderVisible(ctx, int64(tenderID)) {
+		return
+	}
+
+	criteria, err := h.EvaluationService.GetCriteria(int64(tenderID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Tender not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, criteria)
+}
+
+// ScoreBid godoc
+// @Security BearerAuth
+// @Summary Score a bid on qualitative criteria
+// @Description Stores the scores (0-100) the tender owner gives a bid, keyed by criterion ID
+// @Tags Evaluation
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param bid_id path int true "Bid ID"
+// @Param scores body request_model.ScoreBidReq true "Qualitative scores"
+// @Success 200 {object} []model.BidCriterionScore
+// @Failure 400 {object} string "Invalid scores"
+// @Failure 404 {object} string "Bid not found or access denied"
+// @Failure 409 {object} string "Tender cannot be evaluated"
+// @Router /api/client/tenders/{tender_id}/bids/{bid_id}/scores [post]
+func (h *HTTPHandler) ScoreBid(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	bidID, err := strconv.Atoi(ctx.Param("bid_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	req := request_model.ScoreBidReq{}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input. Please check your request format."})
+		return
+	}
+
+	scores, err := h.EvaluationService.ScoreBid(int64(tenderID), int64(bidID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondEvaluationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scores)
+}
+
+// GetBidRanking godoc
+// @Security BearerAuth
+// @Summary Rank the bids of a tender
+// @Description Computes the weighted score of every bid of a closed tender and returns them from best to worst. The ranking is stored so the award can be explained later.
+// @Tags Evaluation
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.BidScore
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Failure 409 {object} string "Tender is still open"
+// @Router /api/client/tenders/{tender_id}/bids/ranking [get]
+func (h *HTTPHandler) GetBidRanking(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	ranking, err := h.EvaluationService.RankBids(int64(tenderID), ctx.GetInt64("user_id"))
+	if err != nil {
+		respondEvaluationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ranking)
+}
+
+func respondEvaluationError(ctx *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "only"), strings.Contains(err.Error(), "cannot"):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
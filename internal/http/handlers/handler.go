@@ -1,24 +1,49 @@
 package handlers
 
 import (
+	"tender-backend/internal/http/token"
+	"tender-backend/internal/pkg/config"
 	server "tender-backend/internal/storage/repo"
+	"tender-backend/internal/usecase/file_storage"
+	"tender-backend/internal/usecase/mailer"
 
 	"github.com/redis/go-redis/v9" // Use v9 Redis package
 	"gorm.io/gorm"
 )
 
 type HTTPHandler struct {
-	UserService   *server.UserService
-	BidService    *server.BidService
-	TenderService *server.TenderService
-	RedisClient   *redis.Client // v9 Redis client
+	UserService       *server.UserService
+	AccountService    *server.AccountService
+	MFAService        *server.MFAService
+	BidService        *server.BidService
+	TenderService     *server.TenderService
+	AttachmentService *server.AttachmentService
+	QuestionService   *server.QuestionService
+	EvaluationService *server.EvaluationService
+	Notifications     *server.NotificationService
+	Organizations     *server.OrganizationService
+	Sessions          *token.SessionStore
+	RedisClient       *redis.Client // v9 Redis client
 }
 
-func NewHttpHandler(db *gorm.DB, RedisClient *redis.Client) *HTTPHandler {
+func NewHttpHandler(db *gorm.DB, RedisClient *redis.Client, storage file_storage.Storage, mail mailer.Mailer) *HTTPHandler {
+	tenderService := server.NewTenderService(db, RedisClient)
+	tenderService.SetStandstill(config.GlobalConfig.Tender.AwardStandstill)
+	accountService := server.NewAccountService(db, RedisClient, mail, config.GlobalConfig.Mail.LinkBaseURL,
+		config.GlobalConfig.Auth.VerificationTTL, config.GlobalConfig.Auth.PasswordResetTTL)
+
 	return &HTTPHandler{
-		UserService:   server.NewUserService(db),
-		BidService:    server.NewBidService(db, RedisClient),
-		TenderService: server.NewTenderService(db, RedisClient),
-		RedisClient:   RedisClient,
+		UserService:       server.NewUserService(db),
+		AccountService:    accountService,
+		MFAService:        server.NewMFAService(db, RedisClient, config.GlobalConfig.Auth.MFAIssuer),
+		BidService:        server.NewBidService(db, RedisClient),
+		TenderService:     tenderService,
+		AttachmentService: server.NewAttachmentService(db, storage, config.GlobalConfig.Storage.MaxUploadSize),
+		QuestionService:   server.NewQuestionService(db, RedisClient, config.GlobalConfig.Tender.QuestionCutoff),
+		EvaluationService: server.NewEvaluationService(db, RedisClient),
+		Notifications:     server.NewNotificationService(db),
+		Organizations:     server.NewOrganizationService(db, mail, config.GlobalConfig.Mail.LinkBaseURL),
+		Sessions:          token.NewSessionStore(RedisClient, config.GlobalConfig.Auth.AccessTokenTTL, config.GlobalConfig.Auth.RefreshTokenTTL),
+		RedisClient:       RedisClient,
 	}
 }
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteContractors godoc
+// @Security BearerAuth
+// @Summary Invite contractors to an invite-only tender
+// @Description Invites contractors by user ID or email. Invitees are notified and can then see and bid on the tender.
+// @Tags Tender
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param invitation body request_model.InviteContractorsReq true "Contractors to invite"
+// @Success 201 {object} []model.TenderInvitation "All invitations of the tender"
+// @Failure 400 {object} string "Invalid invitation"
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/client/tenders/{tender_id}/invitations [post]
+func (h *HTTPHandler) InviteContractors(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req request_model.InviteContractorsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	invitations, err := h.TenderService.InviteContractors(int64(tenderID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid input") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, invitations)
+}
+
+// GetInvitations godoc
+// @Security BearerAuth
+// @Summary List the invitations of a tender
+// @Description Lists the contractors invited to the client's tender
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.TenderInvitation
+// @Failure 404 {object} string "Tender not found"
+// @Router /api/client/tenders/{tender_id}/invitations [get]
+func (h *HTTPHandler) GetInvitations(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	invitations, err := h.TenderService.GetInvitations(int64(tenderID), ctx.GetInt64("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation godoc
+// @Security BearerAuth
+// @Summary Revoke an invitation
+// @Description Removes a contractor's invitation to the client's tender. Bids already placed are kept.
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param contractor_id path int true "Contractor ID"
+// @Success 200 {object} string "Invitation revoked"
+// @Failure 404 {object} string "Invitation not found"
+// @Router /api/client/tenders/{tender_id}/invitations/{contractor_id} [delete]
+func (h *HTTPHandler) RevokeInvitation(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	contractorID, err := strconv.Atoi(ctx.Param("contractor_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contractor ID"})
+		return
+	}
+
+	if err := h.TenderService.RevokeInvitation(int64(tenderID), ctx.GetInt64("user_id"), int64(contractorID)); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	server "tender-backend/internal/storage/repo"
+	request_model "tender-backend/model/request"
+	response_model "tender-backend/model/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollTOTP godoc
+// @Summary Start two-factor authentication enrollment
+// @Description Creates a TOTP secret for an authenticator app. Show the provisioning URI as a QR code, then confirm with a code from the app within 10 minutes.
+// @Tags User
+// @Produce json
+// @Success 200 {object} response_model.TOTPEnrollmentRes
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 409 {object} string "Two-factor authentication is already enabled"
+// @Security BearerAuth
+// @Router /users/mfa/totp [post]
+func (h *HTTPHandler) EnrollTOTP(c *gin.Context) {
+	enrollment, err := h.MFAService.BeginTOTPEnrollment(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP godoc
+// @Summary Enable two-factor authentication
+// @Description Enables two-factor authentication with a code from the authenticator app. Every other session is ended, and the response holds the recovery codes and tokens of a new session that passed two-factor authentication.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param code body request_model.MFACodeReq true "TOTP code"
+// @Success 200 {object} response_model.RecoveryCodesRes
+// @Failure 400 {object} string "No enrollment in progress"
+// @Failure 401 {object} string "Invalid authentication code"
+// @Security BearerAuth
+// @Failure 429 {object} string "Too many wrong authentication codes"
+// @Router /users/mfa/totp/confirm [post]
+func (h *HTTPHandler) ConfirmTOTP(c *gin.Context) {
+	id := c.GetInt64("user_id")
+
+	var req request_model.MFACodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	codes, err := h.MFAService.ConfirmTOTPEnrollment(c.Request.Context(), id, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	// Sessions started with only a password must not outlive the switch
+	if err := h.Sessions.RevokeAll(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := h.Sessions.Start(c.Request.Context(), id, c.GetString("role"), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := loginResponse(tokens)
+	c.JSON(http.StatusOK, response_model.RecoveryCodesRes{RecoveryCodes: codes, Tokens: &res})
+}
+
+// DisableTOTP godoc
+// @Summary Disable two-factor authentication
+// @Description Turns two-factor authentication off and deletes the recovery codes. Needs a TOTP or recovery code.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param code body request_model.MFACodeReq true "TOTP or recovery code"
+// @Success 200 {object} string "Two-factor authentication disabled"
+// @Failure 401 {object} string "Invalid authentication code"
+// @Failure 409 {object} string "Two-factor authentication is not enabled"
+// @Security BearerAuth
+// @Failure 429 {object} string "Too many wrong authentication codes"
+// @Router /users/mfa/totp/disable [post]
+func (h *HTTPHandler) DisableTOTP(c *gin.Context) {
+	var req request_model.MFACodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := h.MFAService.DisableTOTP(c.Request.Context(), c.GetInt64("user_id"), req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace the recovery codes
+// @Description Issues a new set of recovery codes; the old ones stop working. Needs a TOTP or recovery code.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param code body request_model.MFACodeReq true "TOTP or recovery code"
+// @Success 200 {object} response_model.RecoveryCodesRes
+// @Failure 401 {object} string "Invalid authentication code"
+// @Failure 409 {object} string "Two-factor authentication is not enabled"
+// @Security BearerAuth
+// @Failure 429 {object} string "Too many wrong authentication codes"
+// @Router /users/mfa/recovery-codes [post]
+func (h *HTTPHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req request_model.MFACodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	codes, err := h.MFAService.RegenerateRecoveryCodes(c.Request.Context(), c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response_model.RecoveryCodesRes{RecoveryCodes: codes})
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, server.ErrTooManyMFAAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": err.Error()})
+	case errors.Is(err, server.ErrInvalidMFACode), errors.Is(err, server.ErrInvalidMFAChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "Two-factor authentication is"):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateOrganization godoc
+// @Security BearerAuth
+// @Summary Create an organization
+// @Description Creates an organization with the caller as its first owner. Tenders and bids created for it can be managed by all of its managers.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param organization body request_model.CreateOrganizationReq true "Organization name"
+// @Success 201 {object} model.Organization
+// @Failure 400 {object} string "An organization name is required"
+// @Router /api/organizations [post]
+func (h *HTTPHandler) CreateOrganization(ctx *gin.Context) {
+	var req request_model.CreateOrganizationReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	organization, err := h.Organizations.CreateOrganization(ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, organization)
+}
+
+// GetOrganizations godoc
+// @Security BearerAuth
+// @Summary List my organizations
+// @Description Lists the organizations the caller is a member of
+// @Tags Organization
+// @Produce json
+// @Success 200 {object} []model.Organization
+// @Router /api/organizations [get]
+func (h *HTTPHandler) GetOrganizations(ctx *gin.Context) {
+	organizations, err := h.Organizations.GetUserOrganizations(ctx.GetInt64("user_id"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, organizations)
+}
+
+// GetOrganization godoc
+// @Security BearerAuth
+// @Summary Get an organization
+// @Description Returns an organization with its members. Only members can see it.
+// @Tags Organization
+// @Produce json
+// @Param org_id path int true "Organization ID"
+// @Success 200 {object} model.Organization
+// @Failure 404 {object} string "Organization not found or access denied"
+// @Router /api/organizations/{org_id} [get]
+func (h *HTTPHandler) GetOrganization(ctx *gin.Context) {
+	orgID, err := strconv.Atoi(ctx.Param("org_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	organization, err := h.Organizations.GetOrganization(int64(orgID), ctx.GetInt64("user_id"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, organization)
+}
+
+// InviteMember godoc
+// @Security BearerAuth
+// @Summary Invite a member
+// @Description Emails an invitation to join the organization with the given role. It is accepted from an account with that verified email address and expires after 7 days. Only owners can invite.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param org_id path int true "Organization ID"
+// @Param invitation body request_model.InviteMemberReq true "Email and role"
+// @Success 201 {object} model.OrganizationInvitation
+// @Failure 400 {object} string "Invalid role"
+// @Failure 403 {object} string "Only owners can invite"
+// @Failure 409 {object} string "User is already a member"
+// @Router /api/organizations/{org_id}/invitations [post]
+func (h *HTTPHandler) InviteMember(ctx *gin.Context) {
+	orgID, err := strconv.Atoi(ctx.Param("org_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	var req request_model.InviteMemberReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	invitation, err := h.Organizations.InviteMember(ctx.Request.Context(), int64(orgID), ctx.GetInt64("user_id"), &req)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, invitation)
+}
+
+// GetOrganizationInvitations godoc
+// @Security BearerAuth
+// @Summary List the invitations of an organization
+// @Description Lists every invitation of the organization, newest first. Only owners can see them.
+// @Tags Organization
+// @Produce json
+// @Param org_id path int true "Organization ID"
+// @Success 200 {object} []model.OrganizationInvitation
+// @Failure 403 {object} string "Only owners can see invitations"
+// @Router /api/organizations/{org_id}/invitations [get]
+func (h *HTTPHandler) GetOrganizationInvitations(ctx *gin.Context) {
+	orgID, err := strconv.Atoi(ctx.Param("org_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	invitations, err := h.Organizations.GetInvitations(int64(orgID), ctx.GetInt64("user_id"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+// RevokeOrganizationInvitation godoc
+// @Security BearerAuth
+// @Summary Revoke an invitation
+// @Description Withdraws a pending invitation. Only owners can revoke.
+// @Tags Organization
+// @Produce json
+// @Param org_id path int true "Organization ID"
+// @Param invitation_id path int true "Invitation ID"
+// @Success 200 {object} string "Invitation revoked"
+// @Failure 404 {object} string "Invitation not found"
+// @Router /api/organizations/{org_id}/invitations/{invitation_id} [delete]
+func (h *HTTPHandler) RevokeOrganizationInvitation(ctx *gin.Context) {
+	orgID, err := strconv.Atoi(ctx.Param("org_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	invitationID, err := strconv.Atoi(ctx.Param("invitation_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.Organizations.RevokeInvitation(int64(orgID), int64(invitationID), ctx.GetInt64("user_id")); err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// GetMyInvitations godoc
+// @Security BearerAuth
+// @Summary List my invitations
+// @Description Lists the pending invitations sent to the caller's email address
+// @Tags Organization
+// @Produce json
+// @Success 200 {object} []model.OrganizationInvitation
+// @Router /api/organizations/invitations [get]
+func (h *HTTPHandler) GetMyInvitations(ctx *gin.Context) {
+	invitations, err := h.Organizations.GetUserInvitations(ctx.GetInt64("user_id"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+// AcceptInvitation godoc
+// @Security BearerAuth
+// @Summary Accept an invitation
+// @Description Joins the organization with the role of the invitation. The caller's email must be verified and match the invitation.
+// @Tags Organization
+// @Produce json
+// @Param invitation_id path int true "Invitation ID"
+// @Success 200 {object} model.OrganizationMember
+// @Failure 403 {object} string "Email is not verified"
+// @Failure 404 {object} string "Invitation not found"
+// @Failure 409 {object} string "User is already a member"
+// @Router /api/organizations/invitations/{invitation_id}/accept [post]
+func (h *HTTPHandler) AcceptInvitation(ctx *gin.Context) {
+	invitationID, err := strconv.Atoi(ctx.Param("invitation_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	member, err := h.Organizations.AcceptInvitation(int64(invitationID), ctx.GetInt64("user_id"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// DeclineInvitation godoc
+// @Security BearerAuth
+// @Summary Decline an invitation
+// @Description Turns down an invitation sent to the caller's email address
+// @Tags Organization
+// @Produce json
+// @Param invitation_id path int true "Invitation ID"
+// @Success 200 {object} string "Invitation declined"
+// @Failure 404 {object} string "Invitation not found"
+// @Router /api/organizations/invitations/{invitation_id}/decline [post]
+func (h *HTTPHandler) DeclineInvitation(ctx *gin.Context) {
+	invitationID, err := strconv.Atoi(ctx.Param("invitation_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.Organizations.DeclineInvitation(int64(invitationID), ctx.GetInt64("user_id")); err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invitation declined"})
+}
+
+// UpdateMemberRole godoc
+// @Security BearerAuth
+// @Summary Change a member's role
+// @Description Changes the role of a member. Only owners can do this, and the last owner cannot be demoted.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param org_id path int true "Organization ID"
+// @Param user_id path int true "User ID of the member"
+// @Param role body request_model.UpdateMemberReq true "New role"
+// @Success 200 {object} model.OrganizationMember
+// @Failure 400 {object} string "Invalid role"
+// @Failure 404 {object} string "Member not found"
+// @Failure 409 {object} string "An organization needs at least one owner"
+// @Router /api/organizations/{org_id}/members/{user_id} [put]
+func (h *HTTPHandler) UpdateMemberRole(ctx *gin.Context) {
+	orgID, err := strconv.Atoi(ctx.Param("org_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	memberID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req request_model.UpdateMemberReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	member, err := h.Organizations.UpdateMemberRole(int64(orgID), ctx.GetInt64("user_id"), int64(memberID), req.Role)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Security BearerAuth
+// @Summary Remove a member
+// @Description Removes a member from the organization. Owners can remove anyone and every member can remove themselves, except the last owner. Their tenders and bids stay with the organization.
+// @Tags Organization
+// @Produce json
+// @Param org_id path int true "Organization ID"
+// @Param user_id path int true "User ID of the member"
+// @Success 200 {object} string "Member removed"
+// @Failure 404 {object} string "Member not found"
+// @Failure 409 {object} string "An organization needs at least one owner"
+// @Router /api/organizations/{org_id}/members/{user_id} [delete]
+func (h *HTTPHandler) RemoveMember(ctx *gin.Context) {
+	orgID, err := strconv.Atoi(ctx.Param("org_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	memberID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.Organizations.RemoveMember(int64(orgID), ctx.GetInt64("user_id"), int64(memberID)); err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+func respondOrganizationError(ctx *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "Only organization members"), strings.HasPrefix(err.Error(), "Email is not verified"):
+		ctx.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case strings.HasPrefix(err.Error(), "User is already a member"), strings.HasPrefix(err.Error(), "An organization needs"):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	request_model "tender-backend/model/request"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AskQuestion godoc
+// @Summary Ask a clarification question
+// @Description Asks a question about an open tender. Questions close a configurable time before the deadline.
+// @Tags Question
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param question body request_model.AskQuestionReq true "Question"
+// @Success 201 {object} model.TenderQuestion
+// @Failure 400 {object} string "Invalid question"
+// @Failure 404 {object} string "Tender not found"
+// @Failure 409 {object} string "Questions are closed"
+// @Security BearerAuth
+// @Router /api/contractor/tenders/{tender_id}/questions [post]
+func (h *HTTPHandler) AskQuestion(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req request_model.AskQuestionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !h.tenderVisible(c, int64(tenderID)) {
+		return
+	}
+
+	question, err := h.QuestionService.AskQuestion(int64(tenderID), c.GetInt64("user_id"), req.Question)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// GetQuestions godoc
+// @Summary List clarification questions
+// @Description Lists the questions of a tender. Contractors see published answers and their own questions; askers of other questions are anonymized. The tender owner sees every question.
+// @Tags Question
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.TenderQuestion
+// @Failure 404 {object} string "Tender not found"
+// @Security BearerAuth
+// @Router /api/contractor/tenders/{tender_id}/questions [get]
+func (h *HTTPHandler) GetQuestions(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	if !h.tenderVisible(c, int64(tenderID)) {
+		return
+	}
+
+	questions, err := h.QuestionService.GetQuestions(int64(tenderID), c.GetInt64("user_id"))
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// AnswerQuestion godoc
+// @Summary Answer a clarification question
+// @Description Publishes the answer to a question. The asker and every bidder are notified.
+// @Tags Question
+// @Accept json
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param id path int true "Question ID"
+// @Param answer body request_model.AnswerQuestionReq true "Answer"
+// @Success 200 {object} model.TenderQuestion
+// @Failure 400 {object} string "Invalid answer"
+// @Failure 404 {object} string "Question not found"
+// @Security BearerAuth
+// @Router /api/client/tenders/{tender_id}/questions/{id}/answer [post]
+func (h *HTTPHandler) AnswerQuestion(c *gin.Context) {
+	tenderID, err := strconv.Atoi(c.Param("tender_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	var req request_model.AnswerQuestionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	question, err := h.QuestionService.AnswerQuestion(int64(tenderID), int64(questionID), c.GetInt64("user_id"), req.Answer)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
+func respondQuestionError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "closed") || strings.Contains(err.Error(), "not open"):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	server "tender-backend/internal/storage/repo"
 	request_model "tender-backend/model/request"
 
-	"gorm.io/gorm/utils"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -52,7 +53,7 @@ func (h *HTTPHandler) CreateTender(ctx *gin.Context) {
 // GetTender godoc
 // @Security BearerAuth
 // @Summary Get a tender by ID
-// @Description Get a tender by ID
+// @Description Get a tender by ID. Drafts are only found by their owner and the members of its organization, invite-only tenders also by invitees.
 // @Tags Tender
 // @Produce json
 // @Param tender_id path int true "Tender ID"
@@ -66,6 +67,9 @@ func (h *HTTPHandler) GetTender(ctx *gin.Context) {
 	}
 
 	res, err := h.TenderService.GetTenderById(int64(id))
+	if err == nil {
+		err = h.TenderService.CanViewTender(res, ctx.GetInt64("user_id"))
+	}
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Tender not found"})
 		return
@@ -74,18 +78,103 @@ func (h *HTTPHandler) GetTender(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// tenderVisible answers 404 and returns false when the tender does not exist
+// or the caller may not see it, e.g. a draft or an invite-only tender they
+// were not invited to.
+func (h *HTTPHandler) tenderVisible(ctx *gin.Context, tenderID int64) bool {
+	tender, err := h.TenderService.GetTenderById(tenderID)
+	if err == nil {
+		err = h.TenderService.CanViewTender(tender, ctx.GetInt64("user_id"))
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Tender not found"})
+		return false
+	}
+	return true
+}
+
 // GetTenders godoc
 // @Security BearerAuth
 // @Summary Get all tenders
-// @Description Get all tenders
+// @Description Get a filtered, sorted and paginated list of tenders. Drafts are only listed for their owner and the members of its organization, invite-only tenders also for invitees.
 // @Tags Tender
 // @Produce json
-// @Success 200 {object} []model.Tender
+// @Param status query string false "Tender status"
+// @Param client_id query int false "Client ID"
+// @Param organization_id query int false "Organization ID"
+// @Param min_budget query number false "Minimum budget"
+// @Param max_budget query number false "Maximum budget"
+// @Param deadline_from query string false "Deadline lower bound (RFC3339)"
+// @Param deadline_to query string false "Deadline upper bound (RFC3339)"
+// @Param sort_by query string false "Sort field: id, title, deadline, budget, status"
+// @Param sort_order query string false "Sort direction: asc or desc"
+// @Param limit query int false "Page size (max 100)"
+// @Param offset query int false "Number of tenders to skip"
+// @Success 200 {object} response_model.TenderListRes
+// @Failure 400 {object} string "Invalid query parameters"
 // @Router /api/client/tenders [get]
 func (h *HTTPHandler) GetTenders(ctx *gin.Context) {
-	res, err := h.TenderService.GetTenders()
+	filter := request_model.TenderFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query parameters"})
+		return
+	}
 
+	res, err := h.TenderService.GetTenders(&filter, ctx.GetInt64("user_id"))
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid input") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if int64(res.Offset+res.Limit) < res.Total {
+		res.Next = pageLink(ctx, res.Limit, res.Offset+res.Limit)
+	}
+	if res.Offset > 0 {
+		res.Prev = pageLink(ctx, res.Limit, max(res.Offset-res.Limit, 0))
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// pageLink rebuilds the current request URL with a different limit and offset.
+func pageLink(ctx *gin.Context, limit, offset int) string {
+	query := ctx.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+
+	return ctx.Request.URL.Path + "?" + query.Encode()
+}
+
+// SearchTenders godoc
+// @Summary Search tenders
+// @Description Full-text search over tender titles and descriptions
+// @Tags Tender
+// @Produce json
+// @Param q query string true "Search query"
+// @Param status query string false "Tender status"
+// @Param lang query string false "Tender language: en, ru, uz"
+// @Param limit query int false "Page size (max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} response_model.TenderSearchRes
+// @Failure 400 {object} string "Invalid query parameters"
+// @Router /api/tenders/search [get]
+func (h *HTTPHandler) SearchTenders(ctx *gin.Context) {
+	req := request_model.TenderSearchReq{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid query parameters"})
+		return
+	}
+
+	res, err := h.TenderService.SearchTenders(&req, ctx.GetInt64("user_id"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid input") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -103,6 +192,9 @@ func (h *HTTPHandler) GetTenders(ctx *gin.Context) {
 // @Param tender_id path int true "Tender ID"
 // @Param tender body request_model.UpdateTenderReq true "Tender information"
 // @Success 200 {object} model.Tender
+// @Failure 400 {object} string "Invalid tender status"
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Failure 409 {object} string "Status transition not allowed"
 // @Router /api/client/tenders/{tender_id} [put]
 func (h *HTTPHandler) UpdateTender(ctx *gin.Context) {
 	// Get tender ID from the path
@@ -121,8 +213,7 @@ func (h *HTTPHandler) UpdateTender(ctx *gin.Context) {
 		return
 	}
 
-	availableStatus := []string{"open", "closed", "awarded"}
-	if !utils.Contains(availableStatus, req.Status) {
+	if !server.IsTenderStatus(req.Status) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid tender status"})
 		return
 	}
@@ -130,7 +221,7 @@ func (h *HTTPHandler) UpdateTender(ctx *gin.Context) {
 	// Call the service method
 	_, err = h.TenderService.UpdateTender(int64(tenderID), clientID, &req)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": "Tender not found"})
+		respondTenderError(ctx, err)
 		return
 	}
 
@@ -138,13 +229,41 @@ func (h *HTTPHandler) UpdateTender(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Tender status updated"})
 }
 
+// GetTenderHistory godoc
+// @Security BearerAuth
+// @Summary Get tender status history
+// @Description Get every status transition of a tender with its actor and reason
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Success 200 {object} []model.TenderStatusHistory
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Router /api/client/tenders/{tender_id}/history [get]
+func (h *HTTPHandler) GetTenderHistory(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	history, err := h.TenderService.GetTenderHistory(int64(tenderID), ctx.GetInt64("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Tender not found or access denied"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
 // DeleteTender godoc
 // @Security BearerAuth
 // @Summary Delete a tender by ID
-// @Description Delete a tender by ID
+// @Description Withdraw a tender by cancelling it. Its bids and status history are kept.
 // @Tags Tender
 // @Param tender_id path int true "Tender ID"
-// @Success 204
+// @Success 200
+// @Failure 404 {object} string "Tender not found or access denied"
+// @Failure 409 {object} string "Tender is already awarded or cancelled"
 // @Router /api/client/tenders/{tender_id} [delete]
 func (h *HTTPHandler) DeleteTender(ctx *gin.Context) {
 	// Get tender ID from the path
@@ -157,24 +276,44 @@ func (h *HTTPHandler) DeleteTender(ctx *gin.Context) {
 	clientID := ctx.GetInt64("user_id")
 	err = h.TenderService.DeleteTender(int64(tenderID), clientID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"message": "Tender not found or access denied"})
+		respondTenderError(ctx, err)
 		return
 	}
 
-	// Respond with no content
 	ctx.JSON(http.StatusOK, gin.H{"message": "Tender deleted successfully"})
 }
 
+func respondTenderError(ctx *gin.Context, err error) {
+	var transitionErr *server.InvalidTransitionError
+	var sealedErr *server.SealedTenderError
+	if errors.As(err, &transitionErr) || errors.As(err, &sealedErr) {
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid input"):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Tender not found or access denied"})
+	case strings.HasSuffix(err.Error(), "was modified concurrently"):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // AwardTender godoc
 // @Security BearerAuth
 // @Summary Award a tender
-// @Description Award a tender
+// @Description Provisionally awards a tender to a bid. The award becomes final once the standstill period ends without an open appeal.
 // @Tags Tender
 // @Accept json
 // @Produce json
 // @Param tender_id path int true "Tender ID"
 // @Param bid_id path int true "Bid ID"
-// @Success 200 {object} model.Tender
+// @Success 200 {object} model.TenderAward
+// @Failure 409 {object} string "Tender is not being evaluated"
 // @Router /api/client/tenders/{tender_id}/award/{bid_id} [post]
 func (h *HTTPHandler) AwardTender(ctx *gin.Context) {
 	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
@@ -202,12 +341,66 @@ func (h *HTTPHandler) AwardTender(ctx *gin.Context) {
 		return
 	 }
 
-	err = h.TenderService.AwardTender(int64(tenderID), clientID, int64(bidID))
+	award, err := h.TenderService.AwardTender(int64(tenderID), clientID, int64(bidID))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Tender not found or access denied"})
+		var transitionErr *server.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Bid awarded successfully"})
+	ctx.JSON(http.StatusOK, award)
 }
 
+// AwardLot godoc
+// @Security BearerAuth
+// @Summary Award a lot
+// @Description Award one lot of a multi-lot tender to a bid that covers it. Once every lot has a winner the tender enters its standstill period.
+// @Tags Tender
+// @Produce json
+// @Param tender_id path int true "Tender ID"
+// @Param lot_id path int true "Lot ID"
+// @Param bid_id path int true "Bid ID"
+// @Success 200 {object} model.Lot
+// @Failure 400 {object} string "Invalid request"
+// @Failure 409 {object} string "Tender is not being evaluated"
+// @Router /api/client/tenders/{tender_id}/lots/{lot_id}/award/{bid_id} [post]
+func (h *HTTPHandler) AwardLot(ctx *gin.Context) {
+	tenderID, err := strconv.Atoi(ctx.Param("tender_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tender ID"})
+		return
+	}
+
+	lotID, err := strconv.Atoi(ctx.Param("lot_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lot ID"})
+		return
+	}
+
+	bidID, err := strconv.Atoi(ctx.Param("bid_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return
+	}
+
+	lot, err := h.TenderService.AwardLot(int64(tenderID), int64(lotID), ctx.GetInt64("user_id"), int64(bidID))
+	if err != nil {
+		var transitionErr *server.InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lot)
+}
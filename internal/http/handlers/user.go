@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
+	"tender-backend/internal/http/token"
 	"tender-backend/internal/pkg/config"
 	request_model "tender-backend/model/request"
 	response_model "tender-backend/model/response"
@@ -47,7 +49,7 @@ func (h *HTTPHandler) GetUserByID(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Update user by ID
-// @Description Updates a user's information by their ID
+// @Description Updates a user's information by their ID. A new email address has to be verified again.
 // @Tags User
 // @Accept json
 // @Produce json
@@ -79,6 +81,13 @@ func (h *HTTPHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// A changed email address has to be verified again
+	if updatedUser.EmailVerifiedAt == nil {
+		if err := h.AccountService.SendVerification(c.Request.Context(), updatedUser); err != nil {
+			log.Printf("Error sending verification email: %v", err)
+		}
+	}
+
 	profileRes := &response_model.ProfileRes{
 		ID:       updatedUser.ID,
 		FullName: updatedUser.FullName,
@@ -89,6 +98,68 @@ func (h *HTTPHandler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, profileRes)
 }
 
+// ChangePassword godoc
+// @Summary Change password
+// @Description Changes the user's password and revokes every token issued to them. A new session is started for the caller.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param password body request_model.ChangePasswordReq true "Current and new password"
+// @Success 200 {object} response_model.LoginRes "JWT tokens"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Current password is wrong"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /users/password [PUT]
+func (h *HTTPHandler) ChangePassword(c *gin.Context) {
+	id := c.GetInt64("user_id")
+
+	var req request_model.ChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New password cannot be empty"})
+		return
+	}
+
+	user, err := h.UserService.GetUserByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !config.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is wrong"})
+		return
+	}
+
+	hashedPassword, err := config.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error"})
+		return
+	}
+	if err := h.UserService.UpdatePassword(id, hashedPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	if err := h.Sessions.RevokeAll(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed but tokens could not be revoked"})
+		return
+	}
+
+	// The caller keeps the second factor they logged in with
+	claims := c.MustGet("claims").(*token.Claims)
+	tokens, err := h.Sessions.Start(c.Request.Context(), user.ID, user.Role, claims.MFA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse(tokens))
+}
+
 // DeleteUser godoc
 // @Summary Delete user by ID
 // @Description Deletes a user by their ID
@@ -105,6 +176,13 @@ func (h *HTTPHandler) UpdateUser(c *gin.Context) {
 func (h *HTTPHandler) DeleteUser(c *gin.Context) {
 	id := c.GetInt64("user_id")
 
+	// Revoke first: a deleted user whose tokens still work is worse than a
+	// user that was only logged out
+	if err := h.Sessions.RevokeAll(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke tokens"})
+		return
+	}
+
 	err := h.UserService.DeleteUser(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
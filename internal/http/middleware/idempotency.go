@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyHeader     = "Idempotency-Key"
+	maxIdempotencyKeySize = 255
+	// How long a key stays taken while its request runs, in case the server
+	// dies before it can release the key
+	idempotencyPendingTTL = 5 * time.Minute
+)
+
+// idempotentResponse is the record stored in Redis for an Idempotency-Key.
+// It is written with Completed unset when the request starts so that
+// concurrent retries can be told apart from replays.
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// responseRecorder keeps a copy of everything the handler writes.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the original response when a request is
+// retried with the same Idempotency-Key header. Keys are scoped to the
+// authenticated user and kept in Redis for ttl. Requests without the header
+// are passed through unchanged.
+func IdempotencyMiddleware(redisClient *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeySize {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := context.Background()
+		cacheKey := fmt.Sprintf("idempotency_%d_%s", c.GetInt64("user_id"), key)
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		pending, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint})
+		acquired, err := redisClient.SetNX(ctx, cacheKey, pending, min(ttl, idempotencyPendingTTL)).Result()
+		if err != nil {
+			// Without Redis the request is handled as if no key had been sent;
+			// the unique bid index still prevents duplicates
+			log.Printf("idempotency check failed for key %q: %v", key, err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			replayResponse(c, redisClient, cacheKey, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		finished := false
+		defer func() {
+			// A panic is answered with a 500 by the recovery middleware, so
+			// the key is released like for any other server error
+			if !finished {
+				_ = redisClient.Del(ctx, cacheKey).Err()
+			}
+		}()
+		c.Next()
+		finished = true
+
+		status := recorder.Status()
+		// Server errors and rate limiting are not final, so the key is released
+		// and the client may retry
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			_ = redisClient.Del(ctx, cacheKey).Err()
+			return
+		}
+
+		stored, _ := json.Marshal(idempotentResponse{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err := redisClient.Set(ctx, cacheKey, stored, ttl).Err(); err != nil {
+			log.Printf("failed to store idempotent response for key %q: %v", key, err)
+		}
+	}
+}
+
+// replayResponse answers a request whose Idempotency-Key has been seen before.
+func replayResponse(c *gin.Context, redisClient *redis.Client, cacheKey, fingerprint string) {
+	cached, err := redisClient.Get(context.Background(), cacheKey).Bytes()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+		return
+	}
+
+	var previous idempotentResponse
+	if err := json.Unmarshal(cached, &previous); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to read stored response"})
+		return
+	}
+
+	if previous.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+
+	if !previous.Completed {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(previous.Status, previous.ContentType, previous.Body)
+	c.Abort()
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newMemoryRedis()
+
+	calls := 0
+	router := gin.New()
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.POST("/bids", IdempotencyMiddleware(store.client(t), 24*time.Hour), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bids", strings.NewReader(`{"price": 100}`))
+		req.Header.Set(IdempotencyHeader, "retry-me")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request: got %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if len(store.values) != 0 {
+		t.Fatalf("the key is still taken after the panic: %v", store.values)
+	}
+
+	w := send()
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":2}` {
+		t.Fatalf("retry: got %d %s, want the handler's response", w.Code, w.Body.String())
+	}
+
+	w = send()
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":2}` || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("second retry: got %d %s, want the replayed response", w.Code, w.Body.String())
+	}
+	if calls != 2 {
+		t.Fatalf("the handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyPendingKeyExpiresSoon(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newMemoryRedis()
+	client := store.client(t)
+
+	var pendingTTL time.Duration
+	router := gin.New()
+	router.POST("/bids", IdempotencyMiddleware(client, 24*time.Hour), func(c *gin.Context) {
+		pendingTTL = store.ttls["idempotency_0_slow"]
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/bids", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyHeader, "slow")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if pendingTTL != idempotencyPendingTTL {
+		t.Fatalf("the pending key lives for %s, want %s", pendingTTL, idempotencyPendingTTL)
+	}
+	if got := store.ttls["idempotency_0_slow"]; got != 24*time.Hour {
+		t.Fatalf("the stored response lives for %s, want %s", got, 24*time.Hour)
+	}
+}
+
+var errOffline = errors.New("redis is offline in tests")
+
+// memoryRedis answers SET, SET NX, GET and DEL from a map and remembers the
+// expiry each key was set with.
+type memoryRedis struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (m *memoryRedis) client(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+	client.AddHook(m)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (m *memoryRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errOffline
+	}
+}
+
+func (m *memoryRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		m.answer(cmd)
+		return cmd.Err()
+	}
+}
+
+func (m *memoryRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			m.answer(cmd)
+		}
+		return nil
+	}
+}
+
+func (m *memoryRedis) answer(cmd redis.Cmder) {
+	args := cmd.Args()
+	key, _ := args[1].(string)
+
+	switch c := cmd.(type) {
+	case *redis.StringCmd:
+		if value, ok := m.values[key]; ok {
+			c.SetVal(value)
+		} else {
+			c.SetErr(redis.Nil)
+		}
+	case *redis.BoolCmd:
+		// SET NX
+		_, exists := m.values[key]
+		if !exists {
+			m.set(args)
+		}
+		c.SetVal(!exists)
+	case *redis.StatusCmd:
+		m.set(args)
+		c.SetVal("OK")
+	case *redis.IntCmd:
+		var deleted int64
+		for _, arg := range args[1:] {
+			if k, _ := arg.(string); m.values[k] != "" {
+				delete(m.values, k)
+				delete(m.ttls, k)
+				deleted++
+			}
+		}
+		c.SetVal(deleted)
+	default:
+		cmd.SetErr(errOffline)
+	}
+}
+
+// set stores "SET key value [EX seconds | PX milliseconds] [NX]".
+func (m *memoryRedis) set(args []interface{}) {
+	key := args[1].(string)
+	switch value := args[2].(type) {
+	case []byte:
+		m.values[key] = string(value)
+	default:
+		m.values[key] = fmt.Sprint(value)
+	}
+
+	delete(m.ttls, key)
+	for i := 3; i+1 < len(args); i++ {
+		n, _ := args[i+1].(int64)
+		switch args[i] {
+		case "ex":
+			m.ttls[key] = time.Duration(n) * time.Second
+		case "px":
+			m.ttls[key] = time.Duration(n) * time.Millisecond
+		}
+	}
+}
@@ -3,11 +3,15 @@ package middleware
 import (
 	"net/http"
 	"tender-backend/internal/http/token"
+	"tender-backend/internal/pkg/policy"
+	server "tender-backend/internal/storage/repo"
 
 	"github.com/gin-gonic/gin"
 )
 
-func JWTMiddleware() gin.HandlerFunc {
+// JWTMiddleware authenticates the request with its access token and rejects
+// tokens that were revoked, e.g. on logout or when the password changed.
+func JWTMiddleware(sessions *token.SessionStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenStr := c.Request.Header.Get("Authorization")
 
@@ -27,17 +31,50 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := sessions.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Could not verify token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("role", claims.Role)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
 
-func ClientMiddleware() gin.HandlerFunc {
+// OptionalJWTMiddleware identifies the caller of a public route when a token
+// is sent. Requests without a token continue anonymously; an invalid token is
+// still rejected.
+func OptionalJWTMiddleware(sessions *token.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("Authorization") == "" {
+			c.Next()
+			return
+		}
+		JWTMiddleware(sessions)(c)
+	}
+}
+
+// VerifiedEmailMiddleware only lets users with a verified email address
+// through. It runs after JWTMiddleware.
+func VerifiedEmailMiddleware(users *server.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role := c.GetString("role")
-		if role != "client" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only clients can access this resource"})
+		verified, err := users.IsEmailVerified(c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check email verification"})
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first"})
 			c.Abort()
 			return
 		}
@@ -45,11 +82,19 @@ func ClientMiddleware() gin.HandlerFunc {
 	}
 }
 
-func ContractorMiddleware() gin.HandlerFunc {
+// MFAMiddleware only lets sessions that passed two-factor authentication
+// through when required is set, and everyone otherwise. It runs after
+// JWTMiddleware.
+func MFAMiddleware(required bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role := c.GetString("role")
-		if role != "contractor" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only contractors can access this resource"})
+		if !required {
+			c.Next()
+			return
+		}
+
+		claims := c.MustGet("claims").(*token.Claims)
+		if !claims.MFA {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action requires a login with two-factor authentication"})
 			c.Abort()
 			return
 		}
@@ -57,4 +102,23 @@ func ContractorMiddleware() gin.HandlerFunc {
 	}
 }
 
+// Authorize lets the request through when the policy allows the caller's
+// role to perform the action. The services check the caller's relations with
+// the resource, e.g. that they created the tender. It runs after
+// JWTMiddleware, or OptionalJWTMiddleware on routes open to anonymous callers.
+func Authorize(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := policy.Subject{UserID: c.GetInt64("user_id"), Role: c.GetString("role")}
+		if policy.Allowed(subject, action, nil) {
+			c.Next()
+			return
+		}
 
+		if subject.UserID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Missing token"})
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to access this resource"})
+		}
+		c.Abort()
+	}
+}
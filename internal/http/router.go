@@ -1,9 +1,12 @@
 package http
 
 import (
+	"log"
 	_ "tender-backend/docs"
 	"tender-backend/internal/http/handlers"
 	"tender-backend/internal/http/middleware"
+	"tender-backend/internal/pkg/config"
+	"tender-backend/internal/pkg/policy"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +23,21 @@ import (
 // @tag.name Bid
 // @tag.description Bid methods
 
+// @tag.name Evaluation
+// @tag.description Bid evaluation criteria, scoring and ranking
+
+// @tag.name Question
+// @tag.description Clarification questions and answers
+
+// @tag.name Attachment
+// @tag.description Tender and bid documents
+
+// @tag.name Organization
+// @tag.description Organizations, their members and invitations
+
+// @tag.name Admin
+// @tag.description Platform administration
+
 // NewGinRouter godoc
 // @Title Tender API Gateway
 // @Version 1.0
@@ -27,62 +45,172 @@ import (
 // @SecurityDefinitions.apikey BearerAuth
 // @In header
 // @Name Authorization
-func NewGinRouter(h *handlers.HTTPHandler) *gin.Engine {
+func NewGinRouter(h *handlers.HTTPHandler) (*gin.Engine, error) {
 	router := gin.Default()
 
 	swaggerUrl := ginSwagger.URL("swagger/doc.json")
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler, swaggerUrl))
 
+	// Every other route states who may call it: whether it needs a token, the
+	// policy action and the roles the built-in rules let through. See access.go.
+	r := newRoutes(router, h.Sessions)
+
 	// Auth routes
-	router.POST("/login", h.Login)
-	router.POST("/register", h.Register)
-	router.GET("/users/:user_id", h.GetUserByID)
+	r.POST("/login", access{public, "", everyone}, h.Login)
+	r.POST("/login/mfa", access{public, "", everyone}, h.LoginMFA)
+	r.POST("/register", access{public, "", everyone}, h.Register)
+	r.POST("/auth/refresh", access{public, "", everyone}, h.RefreshToken)
+	r.POST("/auth/logout", access{required, policy.ActionAccountManage, users}, h.Logout)
+	r.GET("/.well-known/jwks.json", access{public, "", everyone}, h.GetJWKS)
+	r.POST("/email/verify", access{public, "", everyone}, h.VerifyEmail)
+	r.POST("/email/verify/resend", access{required, policy.ActionAccountManage, users}, h.ResendVerification)
+	r.POST("/password/forgot", access{public, "", everyone}, h.ForgotPassword)
+	r.POST("/password/reset", access{public, "", everyone}, h.ResetPassword)
+	r.GET("/users/:user_id", access{public, "", everyone}, h.GetUserByID)
 
 	// User routes (protected)
-	userGroup := router.Group("/users").Use(middleware.JWTMiddleware())
+	account := access{required, policy.ActionAccountManage, users}
+	userGroup := r.group("/users")
 	{
-		userGroup.PUT("", h.UpdateUser)
-		userGroup.DELETE("", h.DeleteUser)
+		userGroup.PUT("", account, h.UpdateUser)
+		userGroup.PUT("/password", account, h.ChangePassword)
+		userGroup.POST("/mfa/totp", account, h.EnrollTOTP)
+		userGroup.POST("/mfa/totp/confirm", account, h.ConfirmTOTP)
+		userGroup.POST("/mfa/totp/disable", account, h.DisableTOTP)
+		userGroup.POST("/mfa/recovery-codes", account, h.RegenerateRecoveryCodes)
+		userGroup.DELETE("", account, h.DeleteUser)
 	}
 
+	// Creating tenders and bidding need a verified email address
+	verifiedEmail := middleware.VerifiedEmailMiddleware(h.UserService)
+	// Award decisions can be limited to sessions with two-factor authentication
+	awardMFA := middleware.MFAMiddleware(config.GlobalConfig.Auth.RequireMFAForAwards)
+
 	// Tender Routes
-	tenderGroup := router.Group("/api/client/tenders")
+	tenderGroup := r.group("/api/client/tenders")
 	{
-		tenderGroup.GET("/:tender_id", h.GetTender)
-		tenderGroup.GET("", h.GetTenders)
+		// Public reads identify the caller when a token is sent so invitees
+		// can see invite-only tenders
+		read := access{optional, policy.ActionTenderRead, everyone}
+		tenderGroup.GET("/:tender_id", read, h.GetTender)
+		tenderGroup.GET("", read, h.GetTenders)
+		tenderGroup.GET("/:tender_id/versions", read, h.GetTenderVersions)
+		tenderGroup.GET("/:tender_id/versions/:version", read, h.GetTenderVersion)
+		tenderGroup.GET("/:tender_id/criteria", read, h.GetCriteria)
+
+		tenderGroup.POST("", access{required, policy.ActionTenderCreate, clients}, verifiedEmail, h.CreateTender)
+		tenderGroup.PUT("/:tender_id", access{required, policy.ActionTenderUpdate, clients}, h.UpdateTender)
+		tenderGroup.DELETE("/:tender_id", access{required, policy.ActionTenderDelete, clients}, h.DeleteTender)
+		tenderGroup.GET("/:tender_id/history", access{required, policy.ActionTenderReadPrivate, clients}, h.GetTenderHistory)
+		tenderGroup.POST("/:tender_id/amendments", access{required, policy.ActionTenderUpdate, clients}, h.AmendTender)
+		tenderGroup.POST("/:tender_id/questions/:id/answer", access{required, policy.ActionQuestionAnswer, clients}, h.AnswerQuestion)
+		tenderGroup.PUT("/:tender_id/criteria", access{required, policy.ActionTenderUpdate, clients}, h.SetCriteria)
+		tenderGroup.POST("/:tender_id/invitations", access{required, policy.ActionTenderInvite, clients}, h.InviteContractors)
+		tenderGroup.GET("/:tender_id/invitations", access{required, policy.ActionTenderReadPrivate, clients}, h.GetInvitations)
+		tenderGroup.DELETE("/:tender_id/invitations/:contractor_id", access{required, policy.ActionTenderInvite, clients}, h.RevokeInvitation)
+		tenderGroup.GET("/:tender_id/awards", access{required, policy.ActionTenderReadPrivate, clients}, h.GetAwards)
+		tenderGroup.GET("/:tender_id/appeals", access{required, policy.ActionTenderReadPrivate, clients}, h.GetAppeals)
+		tenderGroup.POST("/:tender_id/appeals/:appeal_id/resolve", access{required, policy.ActionTenderAward, clients}, awardMFA, h.ResolveAppeal)
+	}
 
-		protectedTenderGroup := tenderGroup.Use(middleware.JWTMiddleware(), middleware.ClientMiddleware())
-		protectedTenderGroup.POST("", h.CreateTender)
-		protectedTenderGroup.PUT("/:tender_id", h.UpdateTender)
-		protectedTenderGroup.DELETE("/:tender_id", h.DeleteTender)
+	// Search routes
+	r.GET("/api/tenders/search", access{optional, policy.ActionTenderRead, everyone}, h.SearchTenders)
+
+	// Attachment routes
+	r.POST("/api/client/tenders/:tender_id/attachments", access{required, policy.ActionTenderUpdate, clients}, h.UploadTenderAttachment)
+	r.POST("/api/contractor/bids/:bid_id/attachments", access{required, policy.ActionBidUpdate, contractors}, h.UploadBidAttachment)
+
+	documents := access{required, policy.ActionDocumentRead, users}
+	attachmentGroup := r.group("/api")
+	{
+		attachmentGroup.GET("/tenders/:tender_id/attachments", documents, h.GetTenderAttachments)
+		attachmentGroup.GET("/bids/:bid_id/attachments", documents, h.GetBidAttachments)
+		attachmentGroup.GET("/attachments/:attachment_id", documents, h.DownloadAttachment)
 	}
 
 	// Bids routes
-	bidGroup := router.Group("/api/contractor/tenders/:tender_id/bid")
+	bidGroup := r.group("/api/contractor/tenders/:tender_id/bid")
 
-	bidGroup.GET("/:bid_id", h.GetBid)
+	// The bidder and the tender owner may read a bid
+	bidGroup.GET("/:bid_id", access{required, policy.ActionBidRead, []string{policy.RoleClient, policy.RoleContractor}}, h.GetBid)
 
 	bidSubmissionRateLimit := middleware.RateLimitMiddleware(
 		5,           // Max 5 requests
 		time.Minute, // Per minute
 	)
 
-	clientBidsGroup := router.Group("/api/client/tenders/:tender_id/bids")
-	clientBidsGroup.Use(middleware.JWTMiddleware(), middleware.ClientMiddleware())
-	clientBidsGroup.GET("", h.GetBids)
-
-	// Protected POST routes for bids
-	protectedBidGroup := bidGroup.Use(middleware.JWTMiddleware(), middleware.ContractorMiddleware())
-	protectedBidGroup.POST("", bidSubmissionRateLimit, h.CreateBid)
-
-	contractorBidGroup := router.Group("/api/contractor/bids")
-	contractorBidGroup.Use(middleware.JWTMiddleware(), middleware.ContractorMiddleware())
-	contractorBidGroup.GET("", h.GetContractorBids)
-	contractorBidGroup.DELETE("/:bid_id", h.DeleteBid)
+	clientBidsGroup := r.group("/api/client/tenders/:tender_id/bids")
+	clientBidsGroup.GET("", access{required, policy.ActionTenderReadPrivate, clients}, h.GetBids)
+	clientBidsGroup.GET("/ranking", access{required, policy.ActionTenderEvaluate, clients}, h.GetBidRanking)
+	clientBidsGroup.POST("/:bid_id/scores", access{required, policy.ActionTenderEvaluate, clients}, h.ScoreBid)
+	clientBidsGroup.GET("/:bid_id/revisions", access{required, policy.ActionTenderReadPrivate, clients}, h.GetClientBidRevisions)
+
+	// Replays are answered before the rate limiter so retries do not count
+	// against it
+	bidIdempotency := middleware.IdempotencyMiddleware(h.RedisClient, config.GlobalConfig.Idempotency.TTL)
+	bidGroup.POST("", access{required, policy.ActionBidCreate, contractors}, verifiedEmail, bidIdempotency, bidSubmissionRateLimit, h.CreateBid)
+
+	// Clarification question routes. Both parties read the same list; the
+	// service decides what each of them may see.
+	questionGroup := r.group("/api/contractor/tenders/:tender_id/questions")
+	questionGroup.GET("", access{required, policy.ActionQuestionRead, users}, h.GetQuestions)
+	questionGroup.POST("", access{required, policy.ActionQuestionAsk, contractors}, h.AskQuestion)
+
+	// Award appeal routes
+	appealGroup := r.group("/api/contractor/tenders/:tender_id/appeals")
+	appealGroup.GET("", access{required, policy.ActionAppealRead, contractors}, h.GetAppeals)
+	appealGroup.POST("", access{required, policy.ActionAppealFile, contractors}, h.FileAppeal)
+
+	// Live auction routes
+	auctionGroup := r.group("/api/contractor/tenders/:tender_id/auction")
+	auctionGroup.GET("", access{required, policy.ActionAuctionJoin, contractors}, verifiedEmail, h.JoinAuction)
+
+	contractorBidGroup := r.group("/api/contractor/bids")
+	contractorBidGroup.GET("", access{required, policy.ActionBidList, contractors}, h.GetContractorBids)
+	contractorBidGroup.POST("/:bid_id/withdraw", access{required, policy.ActionBidUpdate, contractors}, h.WithdrawBid)
+	contractorBidGroup.PUT("/:bid_id", access{required, policy.ActionBidUpdate, contractors}, verifiedEmail, h.ReviseBid)
+	contractorBidGroup.GET("/:bid_id/revisions", access{required, policy.ActionBidRevisions, contractors}, h.GetBidRevisions)
 
 	// Awards routes
-	awardGroup := tenderGroup.Group("/:tender_id/award")
-	awardGroup.POST("/:bid_id", h.AwardTender)
-
-	return router
+	award := access{required, policy.ActionTenderAward, clients}
+	awardGroup := tenderGroup.group("/:tender_id/award")
+	awardGroup.POST("/:bid_id", award, awardMFA, h.AwardTender)
+	awardGroup.POST("/cancel", award, awardMFA, h.CancelAward)
+	tenderGroup.POST("/:tender_id/lots/:lot_id/award/:bid_id", award, awardMFA, h.AwardLot)
+
+	// Organization routes. Every user may belong to organizations; the
+	// service checks their role in the organization.
+	organizationGroup := r.group("/api/organizations")
+	organizationGroup.POST("", access{required, policy.ActionOrganizationCreate, users}, h.CreateOrganization)
+	organizationGroup.GET("", access{required, policy.ActionOrganizationRead, users}, h.GetOrganizations)
+	organizationGroup.GET("/invitations", access{required, policy.ActionOrganizationJoin, users}, h.GetMyInvitations)
+	organizationGroup.POST("/invitations/:invitation_id/accept", access{required, policy.ActionOrganizationJoin, users}, h.AcceptInvitation)
+	organizationGroup.POST("/invitations/:invitation_id/decline", access{required, policy.ActionOrganizationJoin, users}, h.DeclineInvitation)
+	organizationGroup.GET("/:org_id", access{required, policy.ActionOrganizationRead, users}, h.GetOrganization)
+	organizationGroup.POST("/:org_id/invitations", access{required, policy.ActionOrganizationManage, users}, h.InviteMember)
+	organizationGroup.GET("/:org_id/invitations", access{required, policy.ActionOrganizationManage, users}, h.GetOrganizationInvitations)
+	organizationGroup.DELETE("/:org_id/invitations/:invitation_id", access{required, policy.ActionOrganizationManage, users}, h.RevokeOrganizationInvitation)
+	organizationGroup.PUT("/:org_id/members/:user_id", access{required, policy.ActionOrganizationManage, users}, h.UpdateMemberRole)
+	// Removing others needs organization:manage, leaving organization:leave
+	organizationGroup.DELETE("/:org_id/members/:user_id", access{required, policy.ActionOrganizationLeave, users}, h.RemoveMember)
+
+	// Admin routes
+	adminGroup := r.group("/api/admin")
+	adminGroup.GET("/users", access{required, policy.ActionAdminUsers, admins}, h.ListUsers)
+	adminGroup.GET("/users/:user_id", access{required, policy.ActionAdminUsers, admins}, h.GetUserForAdmin)
+	adminGroup.POST("/users/:user_id/suspend", access{required, policy.ActionAdminUsers, admins}, h.SuspendUser)
+	adminGroup.POST("/users/:user_id/reactivate", access{required, policy.ActionAdminUsers, admins}, h.ReactivateUser)
+	adminGroup.POST("/tenders/:tender_id/close", access{required, policy.ActionAdminTenders, admins}, h.ForceCloseTender)
+	adminGroup.DELETE("/tenders/:tender_id", access{required, policy.ActionAdminTenders, admins}, h.RemoveTender)
+	adminGroup.POST("/tenders/:tender_id/appeals/:appeal_id/resolve", access{required, policy.ActionAdminTenders, admins}, h.AdminResolveAppeal)
+	adminGroup.GET("/bids/:bid_id", access{required, policy.ActionAdminBids, admins}, h.GetBidForAdmin)
+
+	if err := r.verify("/swagger/"); err != nil {
+		return nil, err
+	}
+	// Rules loaded from POLICY_FILE may open or close routes on purpose
+	for _, difference := range r.differences(policy.Current()) {
+		log.Printf("Policy file: %s", difference)
+	}
+	return router, nil
 }
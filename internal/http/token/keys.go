@@ -0,0 +1,183 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jwt "github.com/golang-jwt/jwt"
+)
+
+// Tokens are signed with asymmetric keys, so other services can verify them
+// with the public keys published at /.well-known/jwks.json but cannot issue
+// tokens themselves.
+//
+// Every "<kid>.pem" file in the keys directory holds one private key: RSA
+// (at least 2048 bits) for RS256 or Ed25519 for EdDSA. All of them verify
+// tokens and are published; only the signing key issues new ones. A key is
+// rotated in three steps:
+//  1. add the new key file on every replica and restart, so it is published
+//     and accepted before any token is signed with it;
+//  2. point JWT_SIGNING_KEY_ID at the new key and restart;
+//  3. delete the old key file once ACCESS_TOKEN_TTL has passed and no token
+//     signed with it is still valid.
+
+const minRSAKeyBits = 2048
+
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+type keySet struct {
+	signing *signingKey
+	byID    map[string]*signingKey
+	ids     []string // Sorted, so the JWKS is stable across restarts
+}
+
+var keys *keySet
+
+// JWK is the public part of a signing key as published in the JWKS.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeys reads every key in dir and selects the one that signs new tokens.
+// The signing key may be left empty when the directory holds a single key.
+func LoadKeys(dir, signingKeyID string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read JWT keys directory: %s", err.Error())
+	}
+
+	set := &keySet{byID: make(map[string]*signingKey)}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		key, err := loadKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		set.byID[key.id] = key
+		set.ids = append(set.ids, key.id)
+	}
+	if len(set.ids) == 0 {
+		return fmt.Errorf("no JWT keys found in %s", dir)
+	}
+	sort.Strings(set.ids)
+
+	if signingKeyID == "" {
+		if len(set.ids) > 1 {
+			return errors.New("JWT_SIGNING_KEY_ID must be set when there are several JWT keys")
+		}
+		signingKeyID = set.ids[0]
+	}
+	set.signing = set.byID[signingKeyID]
+	if set.signing == nil {
+		return fmt.Errorf("signing key %q not found in %s", signingKeyID, dir)
+	}
+
+	keys = set
+	return nil
+}
+
+// loadKey parses a PEM encoded PKCS#8 or PKCS#1 private key. The key ID is
+// the file name without its extension.
+func loadKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT key: %s", err.Error())
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT key %s: %s", path, err.Error())
+	}
+
+	key := &signingKey{
+		id:      strings.TrimSuffix(filepath.Base(path), ".pem"),
+		private: parsed,
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("JWT key %s is shorter than %d bits", path, minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+		key.public = &private.PublicKey
+	case ed25519.PrivateKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = private.Public()
+	default:
+		return nil, fmt.Errorf("JWT key %s must be an RSA or Ed25519 key", path)
+	}
+	return key, nil
+}
+
+// verificationKey picks the public key a token claims to be signed with and
+// makes sure the token's algorithm matches it.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keys.byID[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// PublicKeys returns the JWKS of every key that tokens may be signed with.
+func PublicKeys() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys.ids))}
+	for _, id := range keys.ids {
+		key := keys.byID[id]
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
@@ -0,0 +1,229 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"tender-backend/internal/pkg/config"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// TokenPair is what a client receives on login and on every refresh.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    int64 // Unix time the access token expires at
+	Role         string
+}
+
+// refreshRecord is stored in Redis for every refresh token that can still be
+// used, keyed by the token's hash.
+type refreshRecord struct {
+	UserID    int64  `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"session_id"`
+	MFA       bool   `json:"mfa"`
+}
+
+// SessionStore keeps login sessions, refresh tokens and revoked access tokens
+// in Redis. A session starts on login and lives as long as its refresh tokens
+// keep being used; access tokens are only accepted while their session exists.
+type SessionStore struct {
+	redis      *redis.Client
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewSessionStore(redisClient *redis.Client, accessTTL, refreshTTL time.Duration) *SessionStore {
+	return &SessionStore{
+		redis:      redisClient,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Start opens a new session for the user and issues its first token pair.
+// mfa records whether the user passed two-factor authentication; it holds
+// for every token of the session.
+func (s *SessionStore) Start(ctx context.Context, userID int64, role string, mfa bool) (*TokenPair, error) {
+	sessionID, err := config.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, sessionKey(sessionID), userID, s.refreshTTL)
+	pipe.SAdd(ctx, userSessionsKey(userID), sessionID)
+	pipe.Expire(ctx, userSessionsKey(userID), s.refreshTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start session: %s", err.Error())
+	}
+
+	return s.issue(ctx, refreshRecord{UserID: userID, Role: role, SessionID: sessionID, MFA: mfa})
+}
+
+// Refresh rotates a refresh token: the token is consumed and a new pair is
+// issued within the same session. A token that is presented a second time
+// has leaked, so its whole session is revoked.
+func (s *SessionStore) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	data, err := s.redis.GetDel(ctx, refreshKey(refreshToken)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		if err := s.revokeReused(ctx, refreshToken); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refresh token: %s", err.Error())
+	}
+
+	var record refreshRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh token: %s", err.Error())
+	}
+
+	// Logging out and revoking all tokens end the session but leave its
+	// latest refresh token in place
+	active, err := s.redis.Exists(ctx, sessionKey(record.SessionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %s", err.Error())
+	}
+	if active == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, usedRefreshKey(refreshToken), data, s.refreshTTL)
+	pipe.Expire(ctx, sessionKey(record.SessionID), s.refreshTTL)
+	pipe.Expire(ctx, userSessionsKey(record.UserID), s.refreshTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %s", err.Error())
+	}
+
+	return s.issue(ctx, record)
+}
+
+// Logout revokes the access token and ends its session, which also makes the
+// session's refresh token unusable.
+func (s *SessionStore) Logout(ctx context.Context, claims *Claims) error {
+	if remaining := time.Until(time.Unix(claims.ExpiresAt, 0)); remaining > 0 {
+		if err := s.redis.Set(ctx, revokedTokenKey(claims.Id), 1, remaining).Err(); err != nil {
+			return fmt.Errorf("failed to revoke token: %s", err.Error())
+		}
+	}
+	return s.endSession(ctx, claims.UserID, claims.SessionID)
+}
+
+// RevokeAll ends every session of the user, so none of the tokens issued to
+// them so far are accepted any more.
+func (s *SessionStore) RevokeAll(ctx context.Context, userID int64) error {
+	sessionIDs, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %s", err.Error())
+	}
+
+	keys := []string{userSessionsKey(userID)}
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, sessionKey(sessionID))
+	}
+	if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %s", err.Error())
+	}
+	return nil
+}
+
+// IsRevoked reports whether an access token was revoked on its own or its
+// session has ended.
+func (s *SessionStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	pipe := s.redis.Pipeline()
+	revoked := pipe.Exists(ctx, revokedTokenKey(claims.Id))
+	session := pipe.Get(ctx, sessionKey(claims.SessionID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to check token: %s", err.Error())
+	}
+
+	if revoked.Val() > 0 {
+		return true, nil
+	}
+	// The session must exist and belong to the token's user
+	return session.Val() != strconv.FormatInt(claims.UserID, 10), nil
+}
+
+func (s *SessionStore) issue(ctx context.Context, record refreshRecord) (*TokenPair, error) {
+	accessToken, claims, err := GenerateJWT(record.UserID, record.Role, record.SessionID, record.MFA, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := config.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.Set(ctx, refreshKey(refreshToken), data, s.refreshTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %s", err.Error())
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    claims.ExpiresAt,
+		Role:         record.Role,
+	}, nil
+}
+
+// revokeReused ends the session of a refresh token that was already rotated.
+func (s *SessionStore) revokeReused(ctx context.Context, refreshToken string) error {
+	data, err := s.redis.Get(ctx, usedRefreshKey(refreshToken)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read refresh token: %s", err.Error())
+	}
+
+	var record refreshRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("failed to decode refresh token: %s", err.Error())
+	}
+	return s.endSession(ctx, record.UserID, record.SessionID)
+}
+
+func (s *SessionStore) endSession(ctx context.Context, userID int64, sessionID string) error {
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, sessionKey(sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to end session: %s", err.Error())
+	}
+	return nil
+}
+
+func sessionKey(sessionID string) string {
+	return "session_" + sessionID
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("user_sessions_%d", userID)
+}
+
+func refreshKey(token string) string {
+	return "refresh_token_" + config.HashToken(token)
+}
+
+func usedRefreshKey(token string) string {
+	return "refresh_token_used_" + config.HashToken(token)
+}
+
+func revokedTokenKey(tokenID string) string {
+	return "revoked_token_" + tokenID
+}
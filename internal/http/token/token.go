@@ -2,38 +2,54 @@ package token
 
 import (
 	"fmt"
-	"tender-backend/internal/pkg/config"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt"
+	"tender-backend/internal/pkg/config"
 )
 
 type Claims struct {
-	UserID int64  `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    int64  `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	MFA       bool   `json:"mfa"` // The session was started with a second factor
 	jwt.StandardClaims
 }
 
-func GenerateJWT(userID int64, role string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateJWT issues a short-lived access token within a session, signed with
+// the current signing key. Every token gets its own ID (jti) so that it can be
+// revoked on its own.
+func GenerateJWT(userID int64, role, sessionID string, mfa bool, ttl time.Duration) (string, *Claims, error) {
+	tokenID, err := config.RandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
 
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		MFA:       mfa,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        tokenID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(config.GlobalConfig.SecretKey)
+	token := jwt.NewWithClaims(keys.signing.method, claims)
+	token.Header["kid"] = keys.signing.id
+	signed, err := token.SignedString(keys.signing.private)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 func VerifyJWT(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return config.GlobalConfig.SecretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, claims, verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -22,11 +25,72 @@ type RedisConfig struct {
 	RedisPass string
 }
 
+type SchedulerConfig struct {
+	TenderCloseInterval time.Duration
+}
+
+type TenderConfig struct {
+	QuestionCutoff  time.Duration // Questions close this long before the deadline
+	AwardStandstill time.Duration // Losing bidders may appeal an award for this long
+	AuctionOrigins  []string      // Browser origins besides the API's own that may join live auctions
+}
+
+type AuthConfig struct {
+	AccessTokenTTL      time.Duration // Lifetime of an access token
+	RefreshTokenTTL     time.Duration // Lifetime of a refresh token and of an idle session
+	KeysDir             string        // Directory of "<kid>.pem" private keys that sign tokens
+	SigningKeyID        string        // Key that signs new tokens; the others only verify
+	VerificationTTL     time.Duration // How long an email verification link stays valid
+	PasswordResetTTL    time.Duration // How long a password reset link stays valid
+	MFAIssuer           string        // Account issuer shown in authenticator apps
+	RequireMFAForAwards bool          // Award decisions need a session that passed two-factor authentication
+	PolicyFile          string        // Authorization rules replacing the built-in ones, see internal/pkg/policy
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Authentication is skipped when empty
+	Password string
+}
+
+type MailConfig struct {
+	Backend     string // "log", "file" or "smtp"
+	From        string
+	Dir         string // Where the file backend writes .eml files
+	LinkBaseURL string // Frontend URL the links in emails point to
+	SMTP        SMTPConfig
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration // How long a stored response can be replayed
+}
+
+type S3Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+type StorageConfig struct {
+	Backend       string // "local" or "s3"
+	LocalDir      string
+	MaxUploadSize int64 // in bytes
+	S3            S3Config
+}
+
 type Config struct {
-	DB        DBConfig
-	SecretKey []byte
-	AppPort   string
-	Redis     RedisConfig
+	DB          DBConfig
+	AppPort     string
+	Redis       RedisConfig
+	Scheduler   SchedulerConfig
+	Storage     StorageConfig
+	Tender      TenderConfig
+	Idempotency IdempotencyConfig
+	Auth        AuthConfig
+	Mail        MailConfig
 }
 
 var GlobalConfig *Config
@@ -38,7 +102,6 @@ func LoadConfig() {
 	}
 
 	GlobalConfig = &Config{
-		SecretKey: []byte(os.Getenv("JWT_SECRET_KEY")),
 		DB: DBConfig{
 			DBHost:     os.Getenv("DB_HOST"),
 			DBPort:     os.Getenv("DB_PORT"),
@@ -52,6 +115,120 @@ func LoadConfig() {
 			RedisAddr: os.Getenv("REDIS_ADDR"),
 			RedisPass: os.Getenv("REDIS_PASS"),
 		},
+		Scheduler: SchedulerConfig{
+			TenderCloseInterval: getEnvDuration("TENDER_CLOSE_INTERVAL", time.Minute),
+		},
+		Storage: StorageConfig{
+			Backend:       os.Getenv("STORAGE_BACKEND"),
+			LocalDir:      getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+			MaxUploadSize: int64(getEnvInt("MAX_UPLOAD_SIZE_MB", 20)) << 20,
+			S3: S3Config{
+				Endpoint:  os.Getenv("S3_ENDPOINT"),
+				Bucket:    os.Getenv("S3_BUCKET"),
+				Region:    os.Getenv("S3_REGION"),
+				AccessKey: os.Getenv("S3_ACCESS_KEY"),
+				SecretKey: os.Getenv("S3_SECRET_KEY"),
+			},
+		},
+		Tender: TenderConfig{
+			QuestionCutoff:  getEnvDuration("QUESTION_CUTOFF", 24*time.Hour),
+			AwardStandstill: getEnvDuration("AWARD_STANDSTILL", 10*24*time.Hour),
+			AuctionOrigins:  getEnvList("AUCTION_ALLOWED_ORIGINS"),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:      getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:     getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+			KeysDir:             getEnv("JWT_KEYS_DIR", "./keys"),
+			SigningKeyID:        os.Getenv("JWT_SIGNING_KEY_ID"),
+			VerificationTTL:     getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+			PasswordResetTTL:    getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+			MFAIssuer:           getEnv("MFA_ISSUER", "Tender"),
+			RequireMFAForAwards: getEnvBool("REQUIRE_MFA_FOR_AWARDS", false),
+			PolicyFile:          os.Getenv("POLICY_FILE"),
+		},
+		Mail: MailConfig{
+			Backend:     os.Getenv("MAIL_BACKEND"),
+			From:        getEnv("MAIL_FROM", "Tender <noreply@tender.local>"),
+			Dir:         getEnv("MAIL_DIR", "./mail"),
+			LinkBaseURL: getEnv("MAIL_LINK_BASE_URL", "http://localhost:3000"),
+			SMTP: SMTPConfig{
+				Host:     os.Getenv("SMTP_HOST"),
+				Port:     getEnvInt("SMTP_PORT", 587),
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+			},
+		},
 		AppPort: os.Getenv("APP_PORT"),
 	}
 }
+
+// getEnv returns the environment variable or the default when it is unset.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList splits a comma separated environment variable, skipping empty
+// entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt parses an integer from the environment, falling back to the
+// default when the variable is unset or malformed.
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s: %v, using %d", key, err, fallback)
+		return fallback
+	}
+	return number
+}
+
+// getEnvBool parses a boolean such as "true" or "0" from the environment,
+// falling back to the default when the variable is unset or malformed.
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %v, using %t", key, err, fallback)
+		return fallback
+	}
+	return enabled
+}
+
+// getEnvDuration parses a duration such as "30s" or "5m" from the environment,
+// falling back to the default when the variable is unset or malformed.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %v, using %s", key, err, fallback)
+		return fallback
+	}
+	return duration
+}
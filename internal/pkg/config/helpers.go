@@ -1,7 +1,12 @@
 package config
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"regexp"
 
 	"golang.org/x/crypto/bcrypt"
@@ -30,3 +35,20 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// RandomToken returns a URL-safe random token of size bytes, e.g. for refresh
+// tokens and email links.
+func RandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %s", err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken hashes a token before it is stored, so that a Redis dump cannot
+// be used to redeem it.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
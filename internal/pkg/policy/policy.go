@@ -0,0 +1,249 @@
+// Package policy decides who may do what. Every decision is a (subject,
+// action, resource) question: a subject is the caller with their role, an
+// action names an operation such as "tender:award", and a resource is
+// described by the relations the subject has with it, e.g. "creator" of a
+// tender or "org_manager" of the organization that owns it.
+//
+// The rules are data. The built-in ones live in rules.json; POLICY_FILE
+// replaces them with another file in the same format. Anything no rule
+// allows is denied.
+package policy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Roles a rule can name besides the user roles.
+const (
+	RoleAnonymous     = "anonymous" // Callers without a token
+	RoleAuthenticated = "*"         // Any caller with a token
+)
+
+// Roles of the users.
+const (
+	RoleClient     = "client"
+	RoleContractor = "contractor"
+	RoleAdmin      = "admin"
+)
+
+// Actions the application asks about.
+const (
+	ActionAccountManage = "account:manage" // Change one's own account
+
+	ActionTenderRead        = "tender:read"         // Read published tenders
+	ActionTenderCreate      = "tender:create"       //
+	ActionTenderUpdate      = "tender:update"       // Change the status, amend, set criteria, upload documents
+	ActionTenderDelete      = "tender:delete"       //
+	ActionTenderInvite      = "tender:invite"       // Invite contractors to an invite-only tender
+	ActionTenderEvaluate    = "tender:evaluate"     // Score and rank the bids
+	ActionTenderAward       = "tender:award"        // Award, cancel awards, resolve appeals
+	ActionTenderReadPrivate = "tender:read_private" // Bids, history, awards, appeals, questions and invitations
+
+	ActionQuestionRead   = "question:read"
+	ActionQuestionAsk    = "question:ask"
+	ActionQuestionAnswer = "question:answer"
+
+	ActionBidCreate    = "bid:create"
+	ActionBidRead      = "bid:read"      // A single bid
+	ActionBidList      = "bid:list"      // One's own bids
+	ActionBidUpdate    = "bid:update"    // Revise, withdraw, upload documents
+	ActionBidRevisions = "bid:revisions" // The bidder's view of the revisions
+
+	ActionAppealRead   = "appeal:read" // One's own appeals
+	ActionAppealFile   = "appeal:file"
+	ActionAuctionJoin  = "auction:join"
+	ActionDocumentRead = "document:read" // Tender and bid attachments
+
+	ActionOrganizationCreate = "organization:create"
+	ActionOrganizationRead   = "organization:read"   // An organization and its members
+	ActionOrganizationJoin   = "organization:join"   // Answer invitations
+	ActionOrganizationManage = "organization:manage" // Invite, change and remove members
+	ActionOrganizationLeave  = "organization:leave"
+	ActionOrganizationAct    = "organization:act" // Create tenders and bids for the organization
+
+	ActionAdminUsers   = "admin:users"
+	ActionAdminTenders = "admin:tenders"
+	ActionAdminBids    = "admin:bids"
+)
+
+// Relations a subject can have with a resource.
+const (
+	RelationCreator          = "creator"            // Created the tender
+	RelationBidder           = "bidder"             // Submitted the bid, or a bid on the tender, alone or for an organization
+	RelationOrgOwner         = "org_owner"          // Role in the organization the resource belongs to
+	RelationOrgManager       = "org_manager"        //
+	RelationOrgViewer        = "org_viewer"         //
+	RelationTenderCreator    = "tender_creator"     // Created the tender a bid was placed on
+	RelationTenderOrgOwner   = "tender_org_owner"   // Role in the organization of the tender a bid was placed on
+	RelationTenderOrgManager = "tender_org_manager" //
+	RelationTenderOrgViewer  = "tender_org_viewer"  //
+)
+
+var (
+	actions = []string{
+		ActionAccountManage,
+		ActionTenderRead, ActionTenderCreate, ActionTenderUpdate, ActionTenderDelete, ActionTenderInvite,
+		ActionTenderEvaluate, ActionTenderAward, ActionTenderReadPrivate,
+		ActionQuestionRead, ActionQuestionAsk, ActionQuestionAnswer,
+		ActionBidCreate, ActionBidRead, ActionBidList, ActionBidUpdate, ActionBidRevisions,
+		ActionAppealRead, ActionAppealFile, ActionAuctionJoin, ActionDocumentRead,
+		ActionOrganizationCreate, ActionOrganizationRead, ActionOrganizationJoin, ActionOrganizationManage,
+		ActionOrganizationLeave, ActionOrganizationAct,
+		ActionAdminUsers, ActionAdminTenders, ActionAdminBids,
+	}
+	roles = []string{
+		RoleAnonymous, RoleAuthenticated, RoleClient, RoleContractor, RoleAdmin,
+	}
+	relations = []string{
+		RelationCreator, RelationBidder, RelationOrgOwner, RelationOrgManager, RelationOrgViewer,
+		RelationTenderCreator, RelationTenderOrgOwner, RelationTenderOrgManager, RelationTenderOrgViewer,
+	}
+)
+
+// Subject is the caller. Anonymous callers have no user ID and no role.
+type Subject struct {
+	UserID int64
+	Role   string
+}
+
+// Resource is what the subject acts on, as far as the rules care: the
+// relations the subject has with it.
+type Resource struct {
+	Relations []string
+}
+
+// Rule allows subjects with one of Roles to perform Action. With Relations
+// set, the subject also needs one of them with the resource.
+type Rule struct {
+	Action    string   `json:"action"`
+	Roles     []string `json:"roles"`
+	Relations []string `json:"relations,omitempty"`
+}
+
+type ruleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Policy is a validated set of rules.
+type Policy struct {
+	rules map[string][]Rule
+}
+
+//go:embed rules.json
+var defaultRules []byte
+
+var current = Default()
+
+// Load replaces the built-in rules with the ones in the file. It is called
+// once at startup, before any request is served; an empty path keeps the
+// built-in rules.
+func Load(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read policy file: %s", err.Error())
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid policy file %s: %s", path, err.Error())
+	}
+
+	current = p
+	return nil
+}
+
+// Parse reads rules in the format of rules.json. Unknown actions, roles and
+// relations are rejected so that a typo cannot silently deny, or allow,
+// access.
+func Parse(data []byte) (*Policy, error) {
+	var file ruleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Rules) == 0 {
+		return nil, errors.New("no rules")
+	}
+
+	p := &Policy{rules: make(map[string][]Rule)}
+	for i, rule := range file.Rules {
+		if !slices.Contains(actions, rule.Action) {
+			return nil, fmt.Errorf("rule %d: unknown action %q", i+1, rule.Action)
+		}
+		if len(rule.Roles) == 0 {
+			return nil, fmt.Errorf("rule %d: no roles", i+1)
+		}
+		for _, role := range rule.Roles {
+			if !slices.Contains(roles, role) {
+				return nil, fmt.Errorf("rule %d: unknown role %q", i+1, role)
+			}
+		}
+		for _, relation := range rule.Relations {
+			if !slices.Contains(relations, relation) {
+				return nil, fmt.Errorf("rule %d: unknown relation %q", i+1, relation)
+			}
+		}
+		p.rules[rule.Action] = append(p.rules[rule.Action], rule)
+	}
+	return p, nil
+}
+
+// Default returns the built-in rules.
+func Default() *Policy {
+	p, err := Parse(defaultRules)
+	if err != nil {
+		panic("policy: invalid built-in rules: " + err.Error())
+	}
+	return p
+}
+
+// Allowed reports whether a rule lets the subject perform the action on the
+// resource. Without a resource only the role is checked: the route
+// middleware asks whether the subject may perform the action at all, and the
+// service asks again once it knows the resource.
+func (p *Policy) Allowed(subject Subject, action string, resource *Resource) bool {
+	for _, rule := range p.rules[action] {
+		if !rule.appliesTo(subject) {
+			continue
+		}
+		if resource == nil || len(rule.Relations) == 0 {
+			return true
+		}
+		for _, relation := range resource.Relations {
+			if slices.Contains(rule.Relations, relation) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *Rule) appliesTo(subject Subject) bool {
+	if subject.UserID == 0 {
+		return slices.Contains(r.Roles, RoleAnonymous)
+	}
+	return slices.Contains(r.Roles, RoleAuthenticated) || slices.Contains(r.Roles, subject.Role)
+}
+
+// Current returns the loaded rules.
+func Current() *Policy {
+	return current
+}
+
+// Allowed asks the loaded policy.
+func Allowed(subject Subject, action string, resource *Resource) bool {
+	return current.Allowed(subject, action, resource)
+}
+
+// Roles lists the user roles, plus anonymous callers, to check a policy
+// against.
+func Roles() []string {
+	return []string{RoleAnonymous, RoleClient, RoleContractor, RoleAdmin}
+}
@@ -0,0 +1,238 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"not JSON", `{"rules": [`, "unexpected end of JSON input"},
+		{"wrong shape", `{"rules": {"action": "tender:read"}}`, "cannot unmarshal"},
+		{"no rules key", `{}`, "no rules"},
+		{"empty rules", `{"rules": []}`, "no rules"},
+		{"unknown action", `{"rules": [{"action": "tender:fly", "roles": ["*"]}]}`, `rule 1: unknown action "tender:fly"`},
+		{"missing action", `{"rules": [{"roles": ["*"]}]}`, `rule 1: unknown action ""`},
+		{"no roles", `{"rules": [{"action": "tender:read", "roles": ["*"]}, {"action": "tender:create"}]}`, "rule 2: no roles"},
+		{"empty roles", `{"rules": [{"action": "tender:create", "roles": []}]}`, "rule 1: no roles"},
+		{"unknown role", `{"rules": [{"action": "tender:create", "roles": ["client", "owner"]}]}`, `rule 1: unknown role "owner"`},
+		{"roles are case sensitive", `{"rules": [{"action": "tender:create", "roles": ["Client"]}]}`, `rule 1: unknown role "Client"`},
+		{"unknown relation", `{"rules": [{"action": "tender:update", "roles": ["client"], "relations": ["creator", "friend"]}]}`, `rule 1: unknown relation "friend"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse([]byte(tt.data))
+			if err == nil {
+				t.Fatalf("got %v, want an error", p)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	p, err := Parse([]byte(`{"rules": [
+		{"action": "tender:read", "roles": ["anonymous", "*"]},
+		{"action": "question:read", "roles": ["*"]},
+		{"action": "tender:create", "roles": ["client"]},
+		{"action": "tender:update", "roles": ["client"], "relations": ["creator", "org_manager"]},
+		{"action": "bid:read", "roles": ["contractor"], "relations": ["bidder"]},
+		{"action": "bid:read", "roles": ["client"], "relations": ["tender_creator"]},
+		{"action": "admin:users", "roles": ["admin"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anonymous := Subject{}
+	client := Subject{UserID: 1, Role: RoleClient}
+	contractor := Subject{UserID: 2, Role: RoleContractor}
+	admin := Subject{UserID: 3, Role: RoleAdmin}
+	noRole := Subject{UserID: 4}
+	// A role without a user ID is still an anonymous caller
+	forged := Subject{Role: RoleAdmin}
+
+	on := func(relations ...string) *Resource { return &Resource{Relations: relations} }
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		action   string
+		resource *Resource
+		want     bool
+	}{
+		{"anonymous role", anonymous, ActionTenderRead, nil, true},
+		{"anonymous is not authenticated", anonymous, ActionQuestionRead, nil, false},
+		{"anonymous has no user role", anonymous, ActionTenderCreate, nil, false},
+		{"a forged role stays anonymous", forged, ActionAdminUsers, nil, false},
+		{"any authenticated caller", contractor, ActionQuestionRead, nil, true},
+		{"authenticated without a role", noRole, ActionQuestionRead, nil, true},
+		{"without a role no user role matches", noRole, ActionTenderCreate, nil, false},
+		{"role allowed", client, ActionTenderCreate, nil, true},
+		{"role denied", contractor, ActionTenderCreate, nil, false},
+		{"role only rule ignores the resource", client, ActionTenderCreate, on(), true},
+		{"admin is no superuser", admin, ActionTenderCreate, nil, false},
+		{"admin action", admin, ActionAdminUsers, nil, true},
+		{"no rule for the action", admin, ActionTenderDelete, nil, false},
+		{"unknown action", admin, "tender:fly", nil, false},
+
+		{"without a resource only the role counts", client, ActionTenderUpdate, nil, true},
+		{"relation required and missing", client, ActionTenderUpdate, on(), false},
+		{"relation required and present", client, ActionTenderUpdate, on(RelationCreator), true},
+		{"any of the relations", client, ActionTenderUpdate, on(RelationOrgViewer, RelationOrgManager), true},
+		{"other relations only", client, ActionTenderUpdate, on(RelationOrgViewer, RelationBidder), false},
+		{"relation without the role", contractor, ActionTenderUpdate, on(RelationCreator), false},
+
+		{"first of two rules", contractor, ActionBidRead, on(RelationBidder), true},
+		{"second of two rules", client, ActionBidRead, on(RelationTenderCreator), true},
+		{"relation of the other rule", client, ActionBidRead, on(RelationBidder), false},
+		{"role of neither rule", admin, ActionBidRead, on(RelationBidder, RelationTenderCreator), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Allowed(tt.subject, tt.action, tt.resource); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// builtin is who the built-in rules let do what: the roles that may perform
+// an action and the relations they need with the resource, nil meaning none.
+var builtin = map[string]map[string][]string{
+	ActionAccountManage: {RoleClient: nil, RoleContractor: nil, RoleAdmin: nil},
+
+	ActionTenderRead:        {RoleAnonymous: nil, RoleClient: nil, RoleContractor: nil, RoleAdmin: nil},
+	ActionTenderCreate:      {RoleClient: nil},
+	ActionTenderUpdate:      {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager}},
+	ActionTenderDelete:      {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager}},
+	ActionTenderInvite:      {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager}},
+	ActionTenderEvaluate:    {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager}},
+	ActionTenderAward:       {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager}},
+	ActionTenderReadPrivate: {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager, RelationOrgViewer}},
+
+	ActionQuestionRead:   {RoleClient: nil, RoleContractor: nil, RoleAdmin: nil},
+	ActionQuestionAsk:    {RoleContractor: nil},
+	ActionQuestionAnswer: {RoleClient: {RelationCreator, RelationOrgOwner, RelationOrgManager}},
+
+	ActionBidCreate: {RoleContractor: nil},
+	ActionBidRead: {
+		RoleContractor: {RelationBidder, RelationOrgOwner, RelationOrgManager, RelationOrgViewer},
+		RoleClient:     {RelationTenderCreator, RelationTenderOrgOwner, RelationTenderOrgManager, RelationTenderOrgViewer},
+	},
+	ActionBidList:      {RoleContractor: nil},
+	ActionBidUpdate:    {RoleContractor: {RelationBidder, RelationOrgOwner, RelationOrgManager}},
+	ActionBidRevisions: {RoleContractor: {RelationBidder, RelationOrgOwner, RelationOrgManager, RelationOrgViewer}},
+
+	ActionAppealRead:   {RoleContractor: nil},
+	ActionAppealFile:   {RoleContractor: {RelationBidder, RelationOrgOwner, RelationOrgManager}},
+	ActionAuctionJoin:  {RoleContractor: nil},
+	ActionDocumentRead: allUsers(relations...),
+
+	ActionOrganizationCreate: {RoleClient: nil, RoleContractor: nil, RoleAdmin: nil},
+	ActionOrganizationRead:   allUsers(RelationOrgOwner, RelationOrgManager, RelationOrgViewer),
+	ActionOrganizationJoin:   {RoleClient: nil, RoleContractor: nil, RoleAdmin: nil},
+	ActionOrganizationManage: allUsers(RelationOrgOwner),
+	ActionOrganizationLeave:  allUsers(RelationOrgOwner, RelationOrgManager, RelationOrgViewer),
+	ActionOrganizationAct:    allUsers(RelationOrgOwner, RelationOrgManager),
+
+	ActionAdminUsers:   {RoleAdmin: nil},
+	ActionAdminTenders: {RoleAdmin: nil},
+	ActionAdminBids:    {RoleAdmin: nil},
+}
+
+func allUsers(relations ...string) map[string][]string {
+	return map[string][]string{RoleClient: relations, RoleContractor: relations, RoleAdmin: relations}
+}
+
+// TestBuiltinRules asks the built-in rules about every action, for every role
+// with every single relation, no relation and no resource at all.
+func TestBuiltinRules(t *testing.T) {
+	p := Default()
+
+	for _, action := range actions {
+		allowedRoles, ok := builtin[action]
+		if !ok {
+			t.Errorf("%s is missing from the expected rules", action)
+			continue
+		}
+
+		for _, role := range Roles() {
+			subject := Subject{UserID: 1, Role: role}
+			if role == RoleAnonymous {
+				subject = Subject{}
+			}
+			needed, roleAllowed := allowedRoles[role]
+
+			if got := p.Allowed(subject, action, nil); got != roleAllowed {
+				t.Errorf("%s by %s without a resource: got %v, want %v", action, role, got, roleAllowed)
+			}
+			if got, want := p.Allowed(subject, action, &Resource{}), roleAllowed && needed == nil; got != want {
+				t.Errorf("%s by %s without relations: got %v, want %v", action, role, got, want)
+			}
+			for _, relation := range relations {
+				want := roleAllowed && (needed == nil || slices.Contains(needed, relation))
+				if got := p.Allowed(subject, action, &Resource{Relations: []string{relation}}); got != want {
+					t.Errorf("%s by %s as %s: got %v, want %v", action, role, relation, got, want)
+				}
+			}
+		}
+	}
+	if len(builtin) != len(actions) {
+		t.Errorf("the expected rules name %d actions, there are %d", len(builtin), len(actions))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Cleanup(func() { current = Default() })
+	dir := t.TempDir()
+
+	if err := Load(""); err != nil {
+		t.Fatalf("an empty path: %v", err)
+	}
+	if !Allowed(Subject{UserID: 1, Role: RoleClient}, ActionTenderCreate, nil) {
+		t.Fatal("an empty path does not keep the built-in rules")
+	}
+
+	if err := Load(filepath.Join(dir, "missing.json")); err == nil || !strings.Contains(err.Error(), "failed to read policy file") {
+		t.Fatalf("a missing file: got %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"rules": [{"action": "tender:fly", "roles": ["*"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(invalid); err == nil || !strings.Contains(err.Error(), "invalid policy file") {
+		t.Fatalf("an invalid file: got %v", err)
+	}
+	if !Allowed(Subject{UserID: 1, Role: RoleClient}, ActionTenderCreate, nil) {
+		t.Fatal("an invalid file replaced the rules")
+	}
+
+	// Only contractors may create tenders
+	custom := filepath.Join(dir, "custom.json")
+	if err := os.WriteFile(custom, []byte(`{"rules": [{"action": "tender:create", "roles": ["contractor"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(custom); err != nil {
+		t.Fatal(err)
+	}
+	if Allowed(Subject{UserID: 1, Role: RoleClient}, ActionTenderCreate, nil) {
+		t.Fatal("the file did not replace the built-in rules")
+	}
+	if !Allowed(Subject{UserID: 1, Role: RoleContractor}, ActionTenderCreate, nil) {
+		t.Fatal("the rules of the file are not applied")
+	}
+	if Current().Allowed(Subject{UserID: 1, Role: RoleClient}, ActionTenderCreate, nil) {
+		t.Fatal("Current does not return the loaded rules")
+	}
+}
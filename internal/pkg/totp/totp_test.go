@@ -0,0 +1,136 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 secret of the RFC 6238 test vectors, "12345678901234567890",
+// base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238, appendix B, SHA-1. The RFC lists 8 digit codes; with 6 digits
+// they keep their last 6.
+var rfcVectors = []struct {
+	unix int64
+	step int64
+	code string
+}{
+	{59, 0x1, "287082"},
+	{1111111109, 0x23523EC, "081804"},
+	{1111111111, 0x23523ED, "050471"},
+	{1234567890, 0x273EF07, "005924"},
+	{2000000000, 0x3F940AA, "279037"},
+	{20000000000, 0x27BC86AA, "353130"},
+}
+
+func TestGenerateRFCVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, tt := range rfcVectors {
+		if got := generate(key, tt.unix/30); got != tt.code {
+			t.Errorf("T=%d: got %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidateRFCVectors(t *testing.T) {
+	for _, tt := range rfcVectors {
+		step, ok := Validate(rfcSecret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("T=%d: code %s rejected", tt.unix, tt.code)
+			continue
+		}
+		if step != tt.step {
+			t.Errorf("T=%d: got step %#x, want %#x", tt.unix, step, tt.step)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	// 081804 belongs to the step 0x23523EC, i.e. T=1111111080 to 1111111109
+	const (
+		code  = "081804"
+		start = 1111111080
+		step  = 0x23523EC
+	)
+
+	tests := []struct {
+		name string
+		unix int64
+		ok   bool
+	}{
+		{"two steps early", start - 31, false},
+		{"one step early", start - 30, true},
+		{"end of the previous step", start - 1, true},
+		{"start of the step", start, true},
+		{"end of the step", start + 29, true},
+		{"one step late", start + 30, true},
+		{"end of the next step", start + 59, true},
+		{"two steps late", start + 60, false},
+		{"much later", 2000000000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Validate(rfcSecret, code, time.Unix(tt.unix, 0))
+			if ok != tt.ok {
+				t.Fatalf("got %v, want %v", ok, tt.ok)
+			}
+			if ok && got != step {
+				t.Fatalf("got step %#x, want the step of the code, %#x", got, step)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	at := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"empty code", rfcSecret, ""},
+		{"too short", rfcSecret, "28708"},
+		{"too long", rfcSecret, "2870820"},
+		{"the RFC's 8 digit code", rfcSecret, "94287082"},
+		{"code with spaces", rfcSecret, "287 082"},
+		{"letters", rfcSecret, "28708a"},
+		{"wrong code", rfcSecret, "287083"},
+		{"invalid secret", "not base32!", "287082"},
+		{"other secret", "JBSWY3DPEHPK3PXP", "287082"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, at); ok {
+				t.Fatal("code accepted")
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsLowercaseSecret(t *testing.T) {
+	if _, ok := Validate("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", time.Unix(59, 0)); !ok {
+		t.Fatal("code rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != secretSize {
+		t.Fatalf("got a %d byte secret, want %d", len(key), secretSize)
+	}
+
+	now := time.Now()
+	code := generate(key, now.Unix()/30)
+	if _, ok := Validate(secret, code, now); !ok {
+		t.Fatal("the current code of a new secret is rejected")
+	}
+}
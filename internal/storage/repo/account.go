@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"tender-backend/internal/pkg/config"
+	"tender-backend/internal/usecase/mailer"
+	"tender-backend/model"
+)
+
+const (
+	emailVerificationPurpose = "email_verification"
+	passwordResetPurpose     = "password_reset"
+)
+
+var errInvalidAccountToken = errors.New("invalid input: the link is invalid or has expired")
+
+// accountToken is stored in Redis for every verification or reset link that
+// can still be used. The email ties the link to the address it was sent to.
+type accountToken struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// AccountService emails users the links that verify their address and reset
+// their password. The tokens in those links are single-use and expire; Redis
+// only keeps their hashes.
+type AccountService struct {
+	db              *gorm.DB
+	redis           *redis.Client
+	mailer          mailer.Mailer
+	linkBaseURL     string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+}
+
+func NewAccountService(db *gorm.DB, redisClient *redis.Client, mail mailer.Mailer, linkBaseURL string, verificationTTL, resetTTL time.Duration) *AccountService {
+	return &AccountService{
+		db:              db,
+		redis:           redisClient,
+		mailer:          mail,
+		linkBaseURL:     strings.TrimSuffix(linkBaseURL, "/"),
+		verificationTTL: verificationTTL,
+		resetTTL:        resetTTL,
+	}
+}
+
+// SendVerification emails the user a link that confirms their address. Any
+// earlier link stops working.
+func (s *AccountService) SendVerification(ctx context.Context, user *model.User) error {
+	if user.EmailVerifiedAt != nil {
+		return errors.New("Email is already verified")
+	}
+
+	token, err := s.issueToken(ctx, emailVerificationPurpose, user, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address to start creating tenders and bidding:\n\n%s/email/verify?token=%s\n\nThe link expires at %s.\n",
+			user.FullName, s.linkBaseURL, token, time.Now().Add(s.verificationTTL).Format("2006-01-02 15:04 MST")),
+	})
+}
+
+// VerifyEmail marks the address a verification link was sent to as verified.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	record, err := s.consumeToken(ctx, emailVerificationPurpose, token)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Model(&model.User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", record.UserID, record.Email).
+		Update("email_verified_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to verify email: %s", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidAccountToken
+	}
+	return nil
+}
+
+// ForgotPassword emails a password reset link to the user registered with
+// the address. Unknown addresses are ignored so that the response does not
+// reveal which emails have an account.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	var user model.User
+	err := s.db.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find user: %s", err.Error())
+	}
+
+	// Failures from here on are only logged: answering differently for a
+	// registered address would reveal that it has an account.
+	token, err := s.issueToken(ctx, passwordResetPurpose, &user, s.resetTTL)
+	if err != nil {
+		log.Printf("failed to issue password reset token for user %d: %v", user.ID, err)
+		return nil
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nUse this link to choose a new password:\n\n%s/password/reset?token=%s\n\nThe link expires at %s. If you did not ask to reset your password, you can ignore this email.\n",
+			user.FullName, s.linkBaseURL, token, time.Now().Add(s.resetTTL).Format("2006-01-02 15:04 MST")),
+	})
+	if err != nil {
+		log.Printf("failed to send password reset email to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets the password of the user a reset link was sent to and
+// returns their ID. The link proves the user owns the address, so it is
+// verified as well.
+func (s *AccountService) ResetPassword(ctx context.Context, token, hashedPassword string) (int64, error) {
+	record, err := s.consumeToken(ctx, passwordResetPurpose, token)
+	if err != nil {
+		return 0, err
+	}
+
+	result := s.db.Model(&model.User{}).
+		Where("id = ? AND email = ?", record.UserID, record.Email).
+		Updates(map[string]interface{}{
+			"password":          hashedPassword,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, NOW())"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reset password: %s", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return 0, errInvalidAccountToken
+	}
+	return record.UserID, nil
+}
+
+// issueToken stores a new token for the user and drops the previous one of
+// the same purpose, so only the latest link works.
+func (s *AccountService) issueToken(ctx context.Context, purpose string, user *model.User, ttl time.Duration) (string, error) {
+	token, err := config.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(accountToken{UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return "", err
+	}
+
+	latestKey := fmt.Sprintf("%s_user_%d", purpose, user.ID)
+	previous, err := s.redis.Get(ctx, latestKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("failed to read token: %s", err.Error())
+	}
+
+	hash := config.HashToken(token)
+	pipe := s.redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, purpose+"_"+previous)
+	}
+	pipe.Set(ctx, purpose+"_"+hash, data, ttl)
+	pipe.Set(ctx, latestKey, hash, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to store token: %s", err.Error())
+	}
+	return token, nil
+}
+
+// consumeToken redeems a token; a second attempt with the same token fails.
+func (s *AccountService) consumeToken(ctx context.Context, purpose, token string) (*accountToken, error) {
+	if token == "" {
+		return nil, errInvalidAccountToken
+	}
+
+	data, err := s.redis.GetDel(ctx, purpose+"_"+config.HashToken(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errInvalidAccountToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token: %s", err.Error())
+	}
+
+	var record accountToken
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode token: %s", err.Error())
+	}
+	return &record, nil
+}
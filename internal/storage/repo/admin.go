@@ -0,0 +1,295 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+	response_model "tender-backend/model/response"
+)
+
+const RoleAdmin = "admin"
+
+const (
+	defaultUsersLimit = 20
+	maxUsersLimit     = 100
+)
+
+// CreateAdmin creates an administrator. Admins cannot register through the
+// API; this is only called by the create-admin command. Their email counts
+// as verified.
+func (s *UserService) CreateAdmin(fullName, email, username, hashedPassword string) (*model.User, error) {
+	now := time.Now()
+	admin := model.User{
+		FullName:        fullName,
+		Password:        hashedPassword,
+		Email:           email,
+		Role:            RoleAdmin,
+		Username:        username,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := s.db.Create(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errors.New("a user with this email or username already exists")
+		}
+		return nil, err
+	}
+
+	return &admin, nil
+}
+
+// ListUsers returns a page of users matching the filter, newest first.
+func (s *UserService) ListUsers(filter *request_model.UserFilter) (*response_model.UserListRes, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUsersLimit
+	}
+	if filter.Limit > maxUsersLimit {
+		filter.Limit = maxUsersLimit
+	}
+	if filter.Offset < 0 {
+		return nil, errors.New("invalid input: offset cannot be negative")
+	}
+
+	query := s.db.Model(&model.User{})
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(full_name) LIKE ? OR LOWER(email) LIKE ? OR LOWER(username) LIKE ?", pattern, pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	switch filter.Status {
+	case "":
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	default:
+		return nil, errors.New("invalid input: status must be 'active' or 'suspended'")
+	}
+
+	res := response_model.UserListRes{
+		Users:  []response_model.AdminUserRes{},
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if err := query.Count(&res.Total).Error; err != nil {
+		return nil, err
+	}
+
+	var users []model.User
+	if err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for i := range users {
+		res.Users = append(res.Users, *adminUser(&users[i]))
+	}
+
+	return &res, nil
+}
+
+// GetUserForAdmin returns any user as seen by administrators.
+func (s *UserService) GetUserForAdmin(id int64) (*response_model.AdminUserRes, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return adminUser(user), nil
+}
+
+// SuspendUser stops a user from logging in. The caller is expected to revoke
+// the user's tokens. Admins cannot be suspended.
+func (s *UserService) SuspendUser(id int64, reason string) (*response_model.AdminUserRes, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("invalid input: a suspension reason is required")
+	}
+
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == RoleAdmin {
+		return nil, errors.New("Admins cannot be suspended")
+	}
+	if user.SuspendedAt != nil {
+		return nil, errors.New("User is already suspended")
+	}
+
+	now := time.Now()
+	user.SuspendedAt = &now
+	user.SuspensionReason = reason
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"suspended_at":      user.SuspendedAt,
+		"suspension_reason": user.SuspensionReason,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to suspend user: %s", err.Error())
+	}
+
+	return adminUser(user), nil
+}
+
+// ReactivateUser lifts the suspension of a user.
+func (s *UserService) ReactivateUser(id int64) (*response_model.AdminUserRes, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt == nil {
+		return nil, errors.New("User is not suspended")
+	}
+
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"suspended_at":      nil,
+		"suspension_reason": "",
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to reactivate user: %s", err.Error())
+	}
+
+	return adminUser(user), nil
+}
+
+func adminUser(user *model.User) *response_model.AdminUserRes {
+	return &response_model.AdminUserRes{
+		ID:               user.ID,
+		FullName:         user.FullName,
+		Email:            user.Email,
+		Username:         user.Username,
+		Role:             user.Role,
+		EmailVerifiedAt:  user.EmailVerifiedAt,
+		TOTPEnabled:      user.TOTPEnabledAt != nil,
+		SuspendedAt:      user.SuspendedAt,
+		SuspensionReason: user.SuspensionReason,
+	}
+}
+
+// ForceCloseTender lets an administrator stop a tender in any status that is
+// not final by cancelling it. A pending award is cancelled with it, and the
+// client and bidders are told why.
+func (t *TenderService) ForceCloseTender(tenderID, adminID int64, reason string) (*model.Tender, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("invalid input: a reason is required")
+	}
+
+	var (
+		tender        *model.Tender
+		notifications []model.Notification
+	)
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockTender(tx, tenderID)
+		if err != nil {
+			return err
+		}
+		tender = locked
+
+		reason := "closed by an administrator: " + reason
+		if tender.Status == TenderStatusAwardPending {
+			if err := cancelPendingAwards(tx, tender, reason); err != nil {
+				return err
+			}
+		}
+		if err := transitionTender(tx, tender, TenderStatusCancelled, &adminID, reason); err != nil {
+			return err
+		}
+
+		var recipients []int64
+		if err := tx.Model(&model.Bid{}).
+			Where("tender_id = ? AND status <> ?", tender.ID, "withdrawn").
+			Distinct().
+			Pluck("contractor_id", &recipients).Error; err != nil {
+			return err
+		}
+		recipients = append(recipients, tender.ClientID)
+
+		notifications, err = queueNotifications(tx, recipients,
+			fmt.Sprintf("Tender #%d %q has been %s.", tender.ID, tender.Title, reason))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.clearTendersCache()
+	t.notifications.Deliver(notifications)
+
+	return tender, nil
+}
+
+// RemoveTender lets an administrator delete a tender, e.g. one that breaks
+// the platform rules. The client is told why.
+func (t *TenderService) RemoveTender(tenderID int64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("invalid input: a reason is required")
+	}
+
+	tender, err := t.GetTenderById(tenderID)
+	if err != nil {
+		return err
+	}
+
+	var notifications []model.Notification
+	err = t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.Tender{}, tender.ID).Error; err != nil {
+			return err
+		}
+
+		queued, err := queueNotifications(tx, []int64{tender.ClientID},
+			fmt.Sprintf("Your tender #%d %q has been removed by an administrator: %s", tender.ID, tender.Title, reason))
+		if err != nil {
+			return err
+		}
+		notifications = queued
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.clearTendersCache()
+	t.notifications.Deliver(notifications)
+
+	return nil
+}
+
+// AdminResolveAppeal lets an administrator decide an appeal on any tender,
+// e.g. when the client is the one the appeal is about.
+func (t *TenderService) AdminResolveAppeal(tenderID, appealID, adminID int64, req *request_model.ResolveAppealReq) (*model.TenderAppeal, error) {
+	return t.resolveAppeal(tenderID, appealID, adminID, req, func(tx *gorm.DB) (*model.Tender, error) {
+		return lockTender(tx, tenderID)
+	})
+}
+
+// GetBidForAdmin returns any bid with its lots. Bids of sealed tenders stay
+// hidden until the bid opening, for administrators as well.
+func (s *BidService) GetBidForAdmin(bidID int64) (*model.Bid, error) {
+	var bid model.Bid
+	if err := s.db.Preload("Lots").First(&bid, bidID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("bid not found")
+		}
+		return nil, fmt.Errorf("failed to retrieve bid: %s", err.Error())
+	}
+
+	tender, err := s.tenderService.GetTenderById(bid.TenderID)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.isSealed(tender)
+	if err != nil {
+		return nil, err
+	}
+	if sealed {
+		redactBid(&bid)
+	}
+
+	return &bid, nil
+}
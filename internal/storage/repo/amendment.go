@@ -0,0 +1,159 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+// AmendTender changes the terms of a draft or open tender and stores the
+// result as a new version. Contractors who already bid are notified that
+// their bids were submitted against an older version.
+func (t *TenderService) AmendTender(tenderID, clientID int64, req *request_model.AmendTenderReq) (*model.Tender, error) {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderUpdate); err != nil {
+		return nil, err
+	}
+
+	var tender model.Tender
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lots").First(&tender, tenderID).Error; err != nil {
+			return err
+		}
+
+		if tender.Status != TenderStatusDraft && tender.Status != TenderStatusOpen {
+			return errors.New("only draft or open tenders can be amended")
+		}
+
+		if err := applyAmendment(&tender, req); err != nil {
+			return err
+		}
+
+		// Tenders created before versioning have no snapshot of their first version
+		var previous int64
+		if err := tx.Model(&model.TenderVersion{}).Where("tender_id = ?", tender.ID).Count(&previous).Error; err != nil {
+			return err
+		}
+		if previous == 0 {
+			var original model.Tender
+			if err := tx.First(&original, tender.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(tenderVersionSnapshot(&original, original.ClientID, "")).Error; err != nil {
+				return err
+			}
+		}
+
+		tender.Version++
+		if err := tx.Model(&model.Tender{}).Where("id = ?", tender.ID).Updates(map[string]interface{}{
+			"title":       tender.Title,
+			"description": tender.Description,
+			"deadline":    tender.Deadline,
+			"budget":      tender.Budget,
+			"version":     tender.Version,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(tenderVersionSnapshot(&tender, clientID, req.Reason)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate the cache after amending the tender
+	t.clearTendersCache()
+
+	bidderIDs, err := t.GetBidderIDs(tender.ID)
+	if err != nil {
+		log.Printf("failed to fetch bidders of tender %d: %v", tender.ID, err)
+	} else if len(bidderIDs) > 0 {
+		message := fmt.Sprintf("Tender #%d %q was amended to version %d. Your bid was submitted against an earlier version, please review and revise it.",
+			tender.ID, tender.Title, tender.Version)
+		if err := t.notifications.Notify(bidderIDs, message); err != nil {
+			log.Printf("failed to notify bidders of tender %d: %v", tender.ID, err)
+		}
+	}
+
+	return &tender, nil
+}
+
+// applyAmendment copies the non-empty fields of the request onto the tender.
+func applyAmendment(tender *model.Tender, req *request_model.AmendTenderReq) error {
+	if req.Title == "" && req.Description == "" && req.Budget == 0 && req.Deadline.IsZero() {
+		return errors.New("invalid input: amendment changes nothing")
+	}
+
+	if req.Title != "" {
+		tender.Title = req.Title
+	}
+	if req.Description != "" {
+		tender.Description = req.Description
+	}
+	if !req.Deadline.IsZero() {
+		if req.Deadline.Before(time.Now()) {
+			return errors.New("invalid input: deadline must be in the future")
+		}
+		tender.Deadline = req.Deadline
+	}
+	if req.Budget != 0 {
+		if req.Budget < 0 {
+			return errors.New("invalid input: budget must be positive")
+		}
+
+		var lotsTotal float64
+		for _, lot := range tender.Lots {
+			lotsTotal += lot.Budget
+		}
+		if lotsTotal > req.Budget {
+			return errors.New("invalid input: lot budgets exceed the tender budget")
+		}
+		tender.Budget = req.Budget
+	}
+
+	return nil
+}
+
+func tenderVersionSnapshot(tender *model.Tender, actorID int64, reason string) *model.TenderVersion {
+	return &model.TenderVersion{
+		TenderID:    tender.ID,
+		Version:     tender.Version,
+		Title:       tender.Title,
+		Description: tender.Description,
+		Deadline:    tender.Deadline,
+		Budget:      tender.Budget,
+		Reason:      reason,
+		AmendedBy:   actorID,
+	}
+}
+
+// GetTenderVersions returns every stored version of a tender, oldest first.
+func (t *TenderService) GetTenderVersions(tenderID int64) ([]model.TenderVersion, error) {
+	if !t.IsTenderExists(tenderID) {
+		return nil, errors.New("Tender not found or access denied")
+	}
+
+	var versions []model.TenderVersion
+	if err := t.db.Where("tender_id = ?", tenderID).Order("version").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetTenderVersion returns a single version of a tender.
+func (t *TenderService) GetTenderVersion(tenderID int64, version int) (*model.TenderVersion, error) {
+	var tenderVersion model.TenderVersion
+	if err := t.db.Where("tender_id = ? AND version = ?", tenderID, version).First(&tenderVersion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Tender version not found")
+		}
+		return nil, err
+	}
+	return &tenderVersion, nil
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/internal/usecase/file_storage"
+	"tender-backend/model"
+)
+
+// attachmentType is an accepted kind of file.
+type attachmentType struct {
+	contentType string // Served with downloads
+	sniffed     string // What sniffContentType reports for the content of such files
+}
+
+// allowedAttachmentTypes maps accepted file extensions to their type. Office
+// Open XML documents are zip archives, legacy Office documents OLE containers.
+var allowedAttachmentTypes = map[string]attachmentType{
+	".pdf":  {"application/pdf", "application/pdf"},
+	".doc":  {"application/msword", "application/x-ole-storage"},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"},
+	".xls":  {"application/vnd.ms-excel", "application/x-ole-storage"},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "application/zip"},
+	".png":  {"image/png", "image/png"},
+	".jpg":  {"image/jpeg", "image/jpeg"},
+	".jpeg": {"image/jpeg", "image/jpeg"},
+	".zip":  {"application/zip", "application/zip"},
+	".dwg":  {"image/vnd.dwg", "image/vnd.dwg"},
+	".dxf":  {"image/vnd.dxf", "text/plain"},
+	".txt":  {"text/plain", "text/plain"},
+}
+
+// Signatures of formats http.DetectContentType does not know.
+var (
+	oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	dwgSignature = []byte("AC10")
+)
+
+// sniffContentType returns the media type of a file from its first bytes.
+func sniffContentType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, oleSignature):
+		return "application/x-ole-storage"
+	case bytes.HasPrefix(head, dwgSignature):
+		return "image/vnd.dwg"
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+type AttachmentService struct {
+	db      *gorm.DB
+	storage file_storage.Storage
+	maxSize int64
+}
+
+func NewAttachmentService(db *gorm.DB, storage file_storage.Storage, maxSize int64) *AttachmentService {
+	return &AttachmentService{
+		db:      db,
+		storage: storage,
+		maxSize: maxSize,
+	}
+}
+
+// MaxSize is the largest file accepted, in bytes.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// UploadTenderAttachment stores a document of a tender. Only the owner and
+// those the policy lets update the tender may upload.
+func (s *AttachmentService) UploadTenderAttachment(tenderID, clientID int64, file *multipart.FileHeader) (*model.Attachment, error) {
+	var tender model.Tender
+	if err := s.db.First(&tender, tenderID).Error; err != nil {
+		return nil, errors.New("Tender not found or access denied")
+	}
+	owner, err := authorizeTender(s.db, &tender, clientID, policy.ActionTenderUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, errors.New("Tender not found or access denied")
+	}
+
+	return s.upload(&model.Attachment{TenderID: tenderID, UploaderID: clientID}, file)
+}
+
+// UploadBidAttachment stores a proposal document of a bid while its tender is open.
+func (s *AttachmentService) UploadBidAttachment(bidID, contractorID int64, file *multipart.FileHeader) (*model.Attachment, error) {
+	var bid model.Bid
+	if err := s.db.First(&bid, bidID).Error; err != nil {
+		return nil, errors.New("Bid not found or access denied")
+	}
+	owner, err := authorizeBid(s.db, &bid, contractorID, policy.ActionBidUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, errors.New("Bid not found or access denied")
+	}
+
+	var tender model.Tender
+	if err := s.db.First(&tender, bid.TenderID).Error; err != nil {
+		return nil, errors.New("Tender not found")
+	}
+	if tender.Status != TenderStatusOpen {
+		return nil, errors.New("Tender is not open for bids")
+	}
+
+	return s.upload(&model.Attachment{TenderID: bid.TenderID, BidID: &bid.ID, UploaderID: contractorID}, file)
+}
+
+func (s *AttachmentService) upload(attachment *model.Attachment, file *multipart.FileHeader) (*model.Attachment, error) {
+	if file.Size > s.maxSize {
+		return nil, fmt.Errorf("invalid input: file exceeds the limit of %d MB", s.maxSize>>20)
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	fileType, ok := allowedAttachmentTypes[ext]
+	if !ok {
+		return nil, fmt.Errorf("invalid input: file type %q is not allowed", ext)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %s", err.Error())
+	}
+	defer src.Close()
+
+	// The extension is only a claim; the content has to match it
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read upload: %s", err.Error())
+	}
+	head = head[:n]
+	if sniffContentType(head) != fileType.sniffed {
+		return nil, fmt.Errorf("invalid input: file content does not match its %s extension", ext)
+	}
+
+	key, err := attachmentKey(attachment, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash the file while it streams to the storage backend
+	hash := sha256.New()
+	body := io.TeeReader(io.LimitReader(io.MultiReader(bytes.NewReader(head), src), file.Size), hash)
+
+	ctx := context.Background()
+	if err := s.storage.Put(ctx, key, body, file.Size, fileType.contentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %s", err.Error())
+	}
+
+	attachment.FileName = filepath.Base(file.Filename)
+	attachment.ContentType = fileType.contentType
+	attachment.Size = file.Size
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	attachment.StorageKey = key
+
+	if err := s.db.Create(attachment).Error; err != nil {
+		_ = s.storage.Delete(ctx, key)
+		return nil, fmt.Errorf("failed to save attachment: %s", err.Error())
+	}
+
+	return attachment, nil
+}
+
+// attachmentKey builds a unique storage key such as "tenders/12/bids/7/<random>.pdf".
+func attachmentKey(attachment *model.Attachment, ext string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("tenders/%d", attachment.TenderID)
+	if attachment.BidID != nil {
+		key += fmt.Sprintf("/bids/%d", *attachment.BidID)
+	}
+	return key + "/" + hex.EncodeToString(random) + ext, nil
+}
+
+// ListTenderAttachments returns the tender documents visible to the user.
+func (s *AttachmentService) ListTenderAttachments(tenderID, userID int64) ([]model.Attachment, error) {
+	if err := s.checkTenderAccess(tenderID, userID); err != nil {
+		return nil, err
+	}
+
+	var attachments []model.Attachment
+	if err := s.db.Where("tender_id = ? AND bid_id IS NULL", tenderID).Order("id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// ListBidAttachments returns the proposal documents of a bid.
+func (s *AttachmentService) ListBidAttachments(bidID, userID int64) ([]model.Attachment, error) {
+	var bid model.Bid
+	if err := s.db.First(&bid, bidID).Error; err != nil {
+		return nil, errors.New("Bid not found or access denied")
+	}
+	if err := s.checkBidAccess(&bid, userID); err != nil {
+		return nil, err
+	}
+
+	var attachments []model.Attachment
+	if err := s.db.Where("bid_id = ?", bidID).Order("id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// OpenAttachment returns the attachment and its content. Tender documents are
+// available to the tender owner and its bidders; bid documents only to the
+// bidder and, once the bids of a sealed tender are opened, the tender owner.
+// Members of their organizations count as them.
+func (s *AttachmentService) OpenAttachment(attachmentID, userID int64) (*model.Attachment, io.ReadCloser, error) {
+	var attachment model.Attachment
+	if err := s.db.First(&attachment, attachmentID).Error; err != nil {
+		return nil, nil, errors.New("Attachment not found or access denied")
+	}
+
+	if attachment.BidID == nil {
+		if err := s.checkTenderAccess(attachment.TenderID, userID); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var bid model.Bid
+		if err := s.db.First(&bid, *attachment.BidID).Error; err != nil {
+			return nil, nil, errors.New("Attachment not found or access denied")
+		}
+		if err := s.checkBidAccess(&bid, userID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	content, err := s.storage.Get(context.Background(), attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %s", err.Error())
+	}
+
+	return &attachment, content, nil
+}
+
+func (s *AttachmentService) checkTenderAccess(tenderID, userID int64) error {
+	var tender model.Tender
+	if err := s.db.First(&tender, tenderID).Error; err != nil {
+		return errors.New("Attachment not found or access denied")
+	}
+	allowed, err := authorizeTender(s.db, &tender, userID, policy.ActionDocumentRead)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("Attachment not found or access denied")
+	}
+	return nil
+}
+
+// checkBidAccess lets the bidder and the tender owner at the documents of a
+// bid. Like the bid itself, they stay with the bidder until the bid opening
+// of a sealed tender.
+func (s *AttachmentService) checkBidAccess(bid *model.Bid, userID int64) error {
+	relations, err := bidRelations(s.db, bid, userID)
+	if err != nil {
+		return err
+	}
+	allowed, err := authorize(s.db, userID, policy.ActionDocumentRead, func() ([]string, error) {
+		return relations, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("Attachment not found or access denied")
+	}
+	if slices.ContainsFunc(relations, isBidderRelation) {
+		return nil
+	}
+
+	var tender model.Tender
+	if err := s.db.First(&tender, bid.TenderID).Error; err != nil {
+		return errors.New("Attachment not found or access denied")
+	}
+	sealed, err := bidsSealed(s.db, &tender)
+	if err != nil {
+		return err
+	}
+	if sealed {
+		return errors.New("Bid documents are sealed until the bid opening")
+	}
+	return nil
+}
+
+// isBidderRelation reports whether a relation with a bid puts the user on the
+// bidder's side: the bidder or a member of the bidding organization.
+func isBidderRelation(relation string) bool {
+	return relation == policy.RelationBidder || strings.HasPrefix(relation, "org_")
+}
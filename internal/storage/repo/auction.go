@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+	response_model "tender-backend/model/response"
+)
+
+// PlaceAuctionBid stores a live auction bid. The tender row is locked for the
+// duration of the transaction so concurrent bids are compared against the
+// latest lowest price. Bids close to the deadline extend it by the tender's
+// extension window to prevent sniping.
+func (s *BidService) PlaceAuctionBid(req *request_model.CreateBidReq, tenderID, contractorID int64) (*model.Bid, *response_model.AuctionState, error) {
+	if err := s.validateCreateBidRequest(req); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		newBid   model.Bid
+		state    *response_model.AuctionState
+		extended bool
+		lateErr  *LateBidError
+		tender   model.Tender
+	)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tender, tenderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("Tender not found")
+			}
+			return fmt.Errorf("failed to fetch tender: %s", err.Error())
+		}
+
+		allowed, err := canAccessTender(tx, &tender, contractorID)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errors.New("Tender not found")
+		}
+
+		if !tender.Auction {
+			return errors.New("Tender is not an auction")
+		}
+
+		submittedAt := s.now()
+		if submittedAt.After(tender.Deadline) {
+			lateErr = &LateBidError{TenderID: tender.ID, Deadline: tender.Deadline, SubmittedAt: submittedAt}
+			return lateErr
+		}
+
+		if tender.Status != TenderStatusOpen {
+			return errors.New("Tender is not open for bids")
+		}
+
+		current, err := auctionState(tx, &tender)
+		if err != nil {
+			return err
+		}
+
+		if current.BidCount == 0 {
+			if req.Price > tender.Budget {
+				return fmt.Errorf("bid cannot exceed the tender budget of %.2f", tender.Budget)
+			}
+		} else if tender.MinDecrement <= 0 {
+			// Auctions created without a decrement still need a lower bid
+			if req.Price >= current.LowestPrice {
+				return fmt.Errorf("bid must be below %.2f", current.LowestPrice)
+			}
+		} else if req.Price > current.LowestPrice-tender.MinDecrement {
+			return fmt.Errorf("bid must be at most %.2f", current.LowestPrice-tender.MinDecrement)
+		}
+
+		// Each contractor holds one bid per auction; outbidding lowers its
+		// price and keeps the previous price in the revision history
+		existing, err := activeBid(tx, tenderID, contractorID)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			newBid = model.Bid{
+				TenderID:      tenderID,
+				ContractorID:  contractorID,
+				Price:         req.Price,
+				DeliveryTime:  req.DeliveryTime,
+				Comments:      req.Comments,
+				Status:        "pending",
+				TenderVersion: tender.Version,
+			}
+			if err := tx.Create(&newBid).Error; err != nil {
+				return fmt.Errorf("failed to create bid: %s", err.Error())
+			}
+			current.BidCount++
+		} else {
+			newBid = *existing
+			revision := model.BidRevision{
+				BidID:         newBid.ID,
+				Revision:      newBid.Revision,
+				TenderVersion: newBid.TenderVersion,
+				Price:         newBid.Price,
+				DeliveryTime:  newBid.DeliveryTime,
+				Comments:      newBid.Comments,
+				ReplacedAt:    submittedAt,
+			}
+			if err := tx.Create(&revision).Error; err != nil {
+				return fmt.Errorf("failed to store bid revision: %s", err.Error())
+			}
+
+			newBid.Price = req.Price
+			newBid.DeliveryTime = req.DeliveryTime
+			newBid.Comments = req.Comments
+			newBid.TenderVersion = tender.Version
+			newBid.Revision++
+			if err := tx.Model(&model.Bid{}).Where("id = ?", newBid.ID).Updates(map[string]interface{}{
+				"price":          newBid.Price,
+				"delivery_time":  newBid.DeliveryTime,
+				"comments":       newBid.Comments,
+				"tender_version": newBid.TenderVersion,
+				"revision":       newBid.Revision,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update bid: %s", err.Error())
+			}
+		}
+
+		window := time.Duration(tender.ExtensionSeconds) * time.Second
+		if window > 0 && tender.Deadline.Sub(submittedAt) < window {
+			tender.Deadline = submittedAt.Add(window)
+			if err := tx.Model(&tender).Update("deadline", tender.Deadline).Error; err != nil {
+				return fmt.Errorf("failed to extend auction: %s", err.Error())
+			}
+			extended = true
+		}
+
+		state = &response_model.AuctionState{
+			TenderID:     tender.ID,
+			LowestPrice:  req.Price,
+			BidCount:     current.BidCount,
+			MinDecrement: tender.MinDecrement,
+			EndsAt:       tender.Deadline,
+		}
+		return nil
+	})
+	if err != nil {
+		if lateErr != nil {
+			s.recordLateBid(&tender, contractorID, req.Price, lateErr.SubmittedAt)
+		}
+		return nil, nil, err
+	}
+
+	s.clearBidsCache(tenderID)
+	_ = s.redis.Del(context.Background(), fmt.Sprintf("bid_%d_tender_%d", newBid.ID, tenderID)).Err()
+	if extended {
+		s.tenderService.clearTendersCache()
+	}
+
+	return &newBid, state, nil
+}
+
+// GetAuctionState returns the current lowest price and end time of an auction.
+func (s *BidService) GetAuctionState(tenderID int64) (*response_model.AuctionState, error) {
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tender.Auction {
+		return nil, errors.New("Tender is not an auction")
+	}
+
+	return auctionState(s.db, tender)
+}
+
+func auctionState(tx *gorm.DB, tender *model.Tender) (*response_model.AuctionState, error) {
+	state := response_model.AuctionState{
+		TenderID:     tender.ID,
+		MinDecrement: tender.MinDecrement,
+		EndsAt:       tender.Deadline,
+	}
+
+	var summary struct {
+		Lowest float64
+		Count  int64
+	}
+	if err := tx.Model(&model.Bid{}).
+		Select("COALESCE(MIN(price), 0) AS lowest, COUNT(*) AS count").
+		Where("tender_id = ? AND status <> ?", tender.ID, "withdrawn").
+		Scan(&summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch auction state: %s", err.Error())
+	}
+
+	state.LowestPrice = summary.Lowest
+	state.BidCount = summary.Count
+	if state.BidCount == 0 {
+		state.LowestPrice = tender.Budget
+	}
+
+	return &state, nil
+}
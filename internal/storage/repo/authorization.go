@@ -0,0 +1,162 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+)
+
+// The services ask the policy whether a user may act on a tender, bid or
+// organization. The route middleware has already checked the user's role, so
+// this is where their relations with the resource come in.
+
+// authorize asks the policy whether the user may perform the action on a
+// resource with the given relations. The relations are only looked up when
+// the user's role could be allowed at all.
+func authorize(tx *gorm.DB, userID int64, action string, relations func() ([]string, error)) (bool, error) {
+	subject, err := policySubject(tx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !policy.Allowed(subject, action, nil) {
+		return false, nil
+	}
+
+	resource := &policy.Resource{}
+	if resource.Relations, err = relations(); err != nil {
+		return false, err
+	}
+	return policy.Allowed(subject, action, resource), nil
+}
+
+// authorizeTender asks the policy whether the user may perform the action on
+// the tender.
+func authorizeTender(tx *gorm.DB, tender *model.Tender, userID int64, action string) (bool, error) {
+	return authorize(tx, userID, action, func() ([]string, error) {
+		return tenderRelations(tx, tender, userID)
+	})
+}
+
+// authorizeBid asks the policy whether the user may perform the action on the
+// bid.
+func authorizeBid(tx *gorm.DB, bid *model.Bid, userID int64, action string) (bool, error) {
+	return authorize(tx, userID, action, func() ([]string, error) {
+		return bidRelations(tx, bid, userID)
+	})
+}
+
+// authorizeOrganization fails unless the policy lets the user perform the
+// action on the organization. Non-members are told the organization does not
+// exist.
+func authorizeOrganization(tx *gorm.DB, orgID, userID int64, action string) error {
+	relations, err := organizationRelations(tx, &orgID, userID, "")
+	if err != nil {
+		return err
+	}
+	if len(relations) == 0 {
+		return errOrganizationNotFound
+	}
+
+	allowed, err := authorize(tx, userID, action, func() ([]string, error) {
+		return relations, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("Only organization members with a higher role can do this")
+	}
+	return nil
+}
+
+// policySubject returns the user with their current role. Anonymous callers
+// have the user ID 0.
+func policySubject(tx *gorm.DB, userID int64) (policy.Subject, error) {
+	subject := policy.Subject{UserID: userID}
+	if userID == 0 {
+		return subject, nil
+	}
+
+	var user model.User
+	err := tx.Select("role").First(&user, userID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return subject, fmt.Errorf("failed to fetch user: %s", err.Error())
+	}
+	subject.Role = user.Role
+	return subject, nil
+}
+
+// tenderRelations returns the relations of the user with the tender: its
+// creator, a member of its organization, or a bidder on it.
+func tenderRelations(tx *gorm.DB, tender *model.Tender, userID int64) ([]string, error) {
+	var relations []string
+	if tender.ClientID == userID {
+		relations = append(relations, policy.RelationCreator)
+	}
+
+	members, err := organizationRelations(tx, tender.OrganizationID, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	relations = append(relations, members...)
+
+	var bids int64
+	if err := tx.Model(&model.Bid{}).
+		Where("tender_id = ? AND (contractor_id = ? OR organization_id IN (?))", tender.ID, userID, memberOrganizations(tx, userID)).
+		Count(&bids).Error; err != nil {
+		return nil, fmt.Errorf("failed to check bids: %s", err.Error())
+	}
+	if bids > 0 {
+		relations = append(relations, policy.RelationBidder)
+	}
+	return relations, nil
+}
+
+// bidRelations returns the relations of the user with the bid: its bidder, a
+// member of its organization, or the owner of the tender it was placed on.
+func bidRelations(tx *gorm.DB, bid *model.Bid, userID int64) ([]string, error) {
+	var relations []string
+	if bid.ContractorID == userID {
+		relations = append(relations, policy.RelationBidder)
+	}
+
+	members, err := organizationRelations(tx, bid.OrganizationID, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	relations = append(relations, members...)
+
+	var tender model.Tender
+	if err := tx.Select("id", "client_id", "organization_id").First(&tender, bid.TenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return relations, nil
+		}
+		return nil, fmt.Errorf("failed to fetch tender: %s", err.Error())
+	}
+	if tender.ClientID == userID {
+		relations = append(relations, policy.RelationTenderCreator)
+	}
+
+	owners, err := organizationRelations(tx, tender.OrganizationID, userID, "tender_")
+	if err != nil {
+		return nil, err
+	}
+	return append(relations, owners...), nil
+}
+
+// organizationRelations returns the user's role in the organization as a
+// relation, e.g. "org_manager", with the given prefix. A nil organization
+// has no members.
+func organizationRelations(tx *gorm.DB, orgID *int64, userID int64, prefix string) ([]string, error) {
+	if orgID == nil || userID == 0 {
+		return nil, nil
+	}
+	role, err := organizationRole(tx, *orgID, userID)
+	if err != nil || role == "" {
+		return nil, err
+	}
+	return []string{prefix + "org_" + role}, nil
+}
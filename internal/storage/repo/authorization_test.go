@@ -0,0 +1,463 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"tender-backend/internal/usecase/file_storage"
+	"tender-backend/model"
+)
+
+// The authorization tests need a PostgreSQL database. Every test works in a
+// schema of its own that is dropped afterwards:
+//
+//	TEST_DATABASE_DSN="host=localhost port=5434 user=postgres password=1234 dbname=tender sslmode=disable" go test ./internal/storage/repo
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "search_path=" + schema
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := db.AutoMigrate(
+		&model.User{},
+		&model.Tender{}, &model.TenderVersion{}, &model.Lot{}, &model.EvaluationCriterion{},
+		&model.Bid{}, &model.BidLot{}, &model.BidRevision{}, &model.BidCriterionScore{}, &model.BidScore{},
+		&model.Notification{}, &model.LateBidAttempt{}, &model.TenderStatusHistory{},
+		&model.BidOpening{}, &model.Attachment{}, &model.TenderQuestion{}, &model.TenderInvitation{},
+		&model.TenderAward{}, &model.TenderAppeal{}, &model.RecoveryCode{},
+		&model.Organization{}, &model.OrganizationMember{}, &model.OrganizationInvitation{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+var errOffline = errors.New("redis is offline in tests")
+
+// offlineRedis returns a client whose every command fails at once, so the
+// services always miss their caches.
+func offlineRedis(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+	client.AddHook(offline{})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+type offline struct{}
+
+func (offline) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errOffline
+	}
+}
+
+func (offline) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		cmd.SetErr(errOffline)
+		return errOffline
+	}
+}
+
+func (offline) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			cmd.SetErr(errOffline)
+		}
+		return errOffline
+	}
+}
+
+// accessFixture is a tender of the buyer organization in evaluation, with a
+// bid of the supplier organization, and users with and without relations to
+// them.
+type accessFixture struct {
+	db     *gorm.DB
+	tender model.Tender
+	bid    model.Bid
+
+	owner           int64 // Client who created the tender, owner of the buyer
+	buyerManager    int64 // Client, manager of the buyer
+	buyerViewer     int64 // Client, viewer of the buyer
+	bidder          int64 // Contractor who placed the bid, owner of the supplier
+	supplierManager int64 // Contractor, manager of the supplier
+	otherClient     int64
+	otherContractor int64
+	admin           int64
+}
+
+func newAccessFixture(t *testing.T) *accessFixture {
+	t.Helper()
+
+	f := &accessFixture{db: testDB(t)}
+	user := func(name, role string) int64 {
+		u := model.User{FullName: name, Password: "-", Role: role, Email: name + "@example.com", Username: name}
+		if err := f.db.Create(&u).Error; err != nil {
+			t.Fatal(err)
+		}
+		return u.ID
+	}
+	f.owner = user("owner", "client")
+	f.buyerManager = user("buyer-manager", "client")
+	f.buyerViewer = user("buyer-viewer", "client")
+	f.bidder = user("bidder", "contractor")
+	f.supplierManager = user("supplier-manager", "contractor")
+	f.otherClient = user("other-client", "client")
+	f.otherContractor = user("other-contractor", "contractor")
+	f.admin = user("admin", "admin")
+
+	organization := func(name string, members map[int64]string) int64 {
+		org := model.Organization{Name: name, CreatedBy: f.owner}
+		for userID, role := range members {
+			org.Members = append(org.Members, model.OrganizationMember{UserID: userID, Role: role})
+		}
+		if err := f.db.Create(&org).Error; err != nil {
+			t.Fatal(err)
+		}
+		return org.ID
+	}
+	buyer := organization("Buyer", map[int64]string{f.owner: "owner", f.buyerManager: "manager", f.buyerViewer: "viewer"})
+	supplier := organization("Supplier", map[int64]string{f.bidder: "owner", f.supplierManager: "manager"})
+
+	f.tender = model.Tender{
+		ClientID:       f.owner,
+		OrganizationID: &buyer,
+		Title:          "Office chairs",
+		Description:    "40 office chairs",
+		Deadline:       time.Now().Add(-time.Hour),
+		Budget:         1000,
+		Status:         TenderStatusEvaluating,
+	}
+	if err := f.db.Create(&f.tender).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	f.bid = model.Bid{
+		TenderID:       f.tender.ID,
+		ContractorID:   f.bidder,
+		OrganizationID: &supplier,
+		Price:          900,
+		DeliveryTime:   10,
+		Status:         "pending",
+	}
+	if err := f.db.Create(&f.bid).Error; err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// seal makes the tender sealed with its bid opening still ahead.
+func (f *accessFixture) seal(t *testing.T) {
+	t.Helper()
+	if err := f.db.Model(&f.tender).Update("sealed", true).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// open records the bid opening of the sealed tender.
+func (f *accessFixture) open(t *testing.T) {
+	t.Helper()
+	if err := f.db.Create(&model.BidOpening{TenderID: f.tender.ID, BidCount: 1, OpenedAt: time.Now()}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// caller is a user of the fixture and whether they may perform an operation.
+type caller struct {
+	name    string
+	userID  int64
+	allowed bool
+}
+
+func checkAccess(t *testing.T, c caller, err error, denial string) {
+	t.Helper()
+	if c.allowed {
+		if err != nil {
+			t.Fatalf("%s was denied: %v", c.name, err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatalf("%s was let through", c.name)
+	}
+	if err.Error() != denial {
+		t.Fatalf("%s: got %q, want %q", c.name, err.Error(), denial)
+	}
+}
+
+func TestGetBidByIDAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	bids := NewBidService(f.db, offlineRedis(t))
+
+	callers := []caller{
+		{"tender owner", f.owner, true},
+		{"buyer manager", f.buyerManager, true},
+		{"buyer viewer", f.buyerViewer, true},
+		{"bidder", f.bidder, true},
+		{"supplier manager", f.supplierManager, true},
+		{"other client", f.otherClient, false},
+		{"other contractor", f.otherContractor, false},
+		{"admin", f.admin, false},
+		{"anonymous", 0, false},
+	}
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			bid, err := bids.GetBidByID(f.bid.ID, f.tender.ID, c.userID)
+			checkAccess(t, c, err, "Bid not found or access denied")
+			if err == nil && (bid.ID != f.bid.ID || bid.Price != f.bid.Price) {
+				t.Fatalf("got %+v, want the bid", bid)
+			}
+		})
+	}
+}
+
+func TestGetAllBidsAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	bids := NewBidService(f.db, offlineRedis(t))
+
+	callers := []caller{
+		{"tender owner", f.owner, true},
+		{"buyer manager", f.buyerManager, true},
+		{"buyer viewer", f.buyerViewer, true},
+		{"bidder", f.bidder, false},
+		{"supplier manager", f.supplierManager, false},
+		{"other client", f.otherClient, false},
+		{"other contractor", f.otherContractor, false},
+		{"admin", f.admin, false},
+	}
+	for _, c := range callers {
+		t.Run(c.name, func(t *testing.T) {
+			list, err := bids.GetAllBids(f.tender.ID, c.userID)
+			checkAccess(t, c, err, "Tender not found or access denied")
+			if err == nil && (len(list) != 1 || list[0].ID != f.bid.ID) {
+				t.Fatalf("got %+v, want the bid", list)
+			}
+		})
+	}
+}
+
+func TestAwardAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	tenders := NewTenderService(f.db, offlineRedis(t))
+	tenders.SetStandstill(time.Hour)
+
+	const denial = "Tender not found or access denied"
+	outsiders := []caller{
+		{"buyer viewer", f.buyerViewer, false},
+		{"bidder", f.bidder, false},
+		{"supplier manager", f.supplierManager, false},
+		{"other client", f.otherClient, false},
+		{"other contractor", f.otherContractor, false},
+		{"admin", f.admin, false},
+	}
+
+	for _, c := range outsiders {
+		t.Run("award by "+c.name, func(t *testing.T) {
+			_, err := tenders.AwardTender(f.tender.ID, c.userID, f.bid.ID)
+			checkAccess(t, c, err, denial)
+		})
+		t.Run("lot award by "+c.name, func(t *testing.T) {
+			_, err := tenders.AwardLot(f.tender.ID, 1, c.userID, f.bid.ID)
+			checkAccess(t, c, err, denial)
+		})
+	}
+	assertTenderStatus(t, f, TenderStatusEvaluating)
+
+	t.Run("award by the tender owner", func(t *testing.T) {
+		award, err := tenders.AwardTender(f.tender.ID, f.owner, f.bid.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if award.BidID != f.bid.ID || award.Status != AwardStatusPending {
+			t.Fatalf("got %+v, want a pending award of the bid", award)
+		}
+	})
+	assertTenderStatus(t, f, TenderStatusAwardPending)
+
+	readers := []caller{
+		{"tender owner", f.owner, true},
+		{"buyer manager", f.buyerManager, true},
+		{"buyer viewer", f.buyerViewer, true},
+		{"bidder", f.bidder, false},
+		{"other client", f.otherClient, false},
+		{"admin", f.admin, false},
+	}
+	for _, c := range readers {
+		t.Run("awards read by "+c.name, func(t *testing.T) {
+			awards, err := tenders.GetAwards(f.tender.ID, c.userID)
+			checkAccess(t, c, err, denial)
+			if err == nil && len(awards) != 1 {
+				t.Fatalf("got %d awards, want 1", len(awards))
+			}
+		})
+	}
+
+	for _, c := range outsiders {
+		t.Run("cancellation by "+c.name, func(t *testing.T) {
+			err := tenders.CancelAward(f.tender.ID, c.userID, "changed my mind")
+			checkAccess(t, c, err, denial)
+		})
+	}
+	assertTenderStatus(t, f, TenderStatusAwardPending)
+
+	t.Run("cancellation by the buyer manager", func(t *testing.T) {
+		if err := tenders.CancelAward(f.tender.ID, f.buyerManager, "wrong bid"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assertTenderStatus(t, f, TenderStatusEvaluating)
+}
+
+func assertTenderStatus(t *testing.T, f *accessFixture, want string) {
+	t.Helper()
+	var tender model.Tender
+	if err := f.db.First(&tender, f.tender.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if tender.Status != want {
+		t.Fatalf("the tender is %s, want %s", tender.Status, want)
+	}
+}
+
+func TestOpenAttachmentAccess(t *testing.T) {
+	f := newAccessFixture(t)
+	storage := file_storage.NewLocalStorage(t.TempDir())
+	attachments := NewAttachmentService(f.db, storage, 1<<20)
+
+	document := func(key string, bidID *int64, uploader int64) model.Attachment {
+		content := []byte("%PDF- " + key)
+		if err := storage.Put(context.Background(), key, bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+			t.Fatal(err)
+		}
+		attachment := model.Attachment{
+			TenderID:    f.tender.ID,
+			BidID:       bidID,
+			UploaderID:  uploader,
+			FileName:    key,
+			ContentType: "application/pdf",
+			Size:        int64(len(content)),
+			SHA256:      "-",
+			StorageKey:  key,
+		}
+		if err := f.db.Create(&attachment).Error; err != nil {
+			t.Fatal(err)
+		}
+		return attachment
+	}
+	tenderDocument := document("specification.pdf", nil, f.owner)
+	bidDocument := document("proposal.pdf", &f.bid.ID, f.bidder)
+
+	open := func(t *testing.T, c caller, attachment model.Attachment, denial string) {
+		t.Helper()
+		opened, content, err := attachments.OpenAttachment(attachment.ID, c.userID)
+		checkAccess(t, c, err, denial)
+		if err != nil {
+			return
+		}
+		defer content.Close()
+		data, err := io.ReadAll(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened.ID != attachment.ID || string(data) != "%PDF- "+attachment.StorageKey {
+			t.Fatalf("got attachment %d with %q, want %d", opened.ID, data, attachment.ID)
+		}
+	}
+
+	const denial = "Attachment not found or access denied"
+	const sealed = "Bid documents are sealed until the bid opening"
+
+	// The tender owner's side and the bidder's side see both documents
+	callers := []caller{
+		{"tender owner", f.owner, true},
+		{"buyer manager", f.buyerManager, true},
+		{"buyer viewer", f.buyerViewer, true},
+		{"bidder", f.bidder, true},
+		{"supplier manager", f.supplierManager, true},
+		{"other client", f.otherClient, false},
+		{"other contractor", f.otherContractor, false},
+		{"admin", f.admin, false},
+	}
+	for _, c := range callers {
+		t.Run("tender document for "+c.name, func(t *testing.T) {
+			open(t, c, tenderDocument, denial)
+		})
+		t.Run("bid document for "+c.name, func(t *testing.T) {
+			open(t, c, bidDocument, denial)
+		})
+	}
+
+	// Until the bid opening of a sealed tender the bid documents stay with
+	// the bidder's side
+	f.seal(t)
+	callers = []caller{
+		{"tender owner", f.owner, false},
+		{"buyer manager", f.buyerManager, false},
+		{"buyer viewer", f.buyerViewer, false},
+		{"bidder", f.bidder, true},
+		{"supplier manager", f.supplierManager, true},
+	}
+	for _, c := range callers {
+		t.Run("sealed bid document for "+c.name, func(t *testing.T) {
+			open(t, c, bidDocument, sealed)
+		})
+	}
+	t.Run("sealed bid document for other client", func(t *testing.T) {
+		open(t, caller{"other client", f.otherClient, false}, bidDocument, denial)
+	})
+
+	f.open(t)
+	for _, c := range callers {
+		c.allowed = true
+		t.Run("opened bid document for "+c.name, func(t *testing.T) {
+			open(t, c, bidDocument, denial)
+		})
+	}
+}
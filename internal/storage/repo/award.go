@@ -0,0 +1,751 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+// Award and appeal statuses.
+const (
+	AwardStatusPending   = "pending"
+	AwardStatusFinal     = "final"
+	AwardStatusCancelled = "cancelled"
+
+	AppealStatusOpen      = "open"
+	AppealStatusUpheld    = "upheld"
+	AppealStatusDismissed = "dismissed"
+	AppealStatusClosed    = "closed"
+)
+
+// AwardTender provisionally awards the tender to a bid. In a single
+// transaction the tender and its bids are locked, the award is recorded as
+// pending and the tender enters its standstill period, during which losing
+// bidders may appeal. Bids are only accepted and rejected once the award
+// becomes final. Withdrawn bids are left untouched.
+func (t *TenderService) AwardTender(tenderID, clientID, bidID int64) (*model.TenderAward, error) {
+	var (
+		award         model.TenderAward
+		bidIDs        []int64
+		notifications []model.Notification
+	)
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		tender, err := lockClientTender(tx, tenderID, clientID)
+		if err != nil {
+			return err
+		}
+
+		var lots int64
+		if err := tx.Model(&model.Lot{}).Where("tender_id = ?", tenderID).Count(&lots).Error; err != nil {
+			return err
+		}
+		if lots > 0 {
+			return errors.New("tender has lots: award each lot separately")
+		}
+
+		bids, err := lockActiveBids(tx, tenderID)
+		if err != nil {
+			return err
+		}
+
+		var winner *model.Bid
+		for i := range bids {
+			bidIDs = append(bidIDs, bids[i].ID)
+			if bids[i].ID == bidID {
+				winner = &bids[i]
+			}
+		}
+		if winner == nil {
+			return errors.New("bid not found or access denied")
+		}
+
+		if err := transitionTender(tx, tender, TenderStatusAwardPending, &clientID, fmt.Sprintf("provisionally awarded to bid %d", bidID)); err != nil {
+			return err
+		}
+
+		awards := []model.TenderAward{{
+			TenderID:     tenderID,
+			BidID:        winner.ID,
+			ContractorID: winner.ContractorID,
+			Status:       AwardStatusPending,
+			AwardedBy:    clientID,
+		}}
+		queued, err := t.startStandstill(tx, tender, awards, bids, clientID)
+		if err != nil {
+			return err
+		}
+		notifications = queued
+		award = awards[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate the caches after awarding the tender
+	t.clearTendersCache()
+	t.clearBidsCache(tenderID, bidIDs)
+
+	t.notifications.Deliver(notifications)
+
+	return &award, nil
+}
+
+// AwardLot provisionally awards one lot of a multi-lot tender to a bid that
+// covers it. Once every lot has a winner the tender enters its standstill
+// period like a single award.
+func (t *TenderService) AwardLot(tenderID, lotID, clientID, bidID int64) (*model.Lot, error) {
+	var (
+		lot           model.Lot
+		bidIDs        []int64
+		notifications []model.Notification
+	)
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		tender, err := lockClientTender(tx, tenderID, clientID)
+		if err != nil {
+			return err
+		}
+
+		if tender.Status != TenderStatusEvaluating {
+			return &InvalidTransitionError{From: tender.Status, To: TenderStatusAwardPending}
+		}
+
+		if err := tx.Where("id = ? AND tender_id = ?", lotID, tenderID).First(&lot).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("lot not found or access denied")
+			}
+			return err
+		}
+
+		var bid model.Bid
+		if err := tx.Joins("JOIN bid_lots ON bid_lots.bid_id = bids.id").
+			Where("bids.id = ? AND bids.tender_id = ? AND bid_lots.lot_id = ? AND bids.status <> ?", bidID, tenderID, lotID, "withdrawn").
+			First(&bid).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("bid not found or does not cover this lot")
+			}
+			return err
+		}
+
+		lot.AwardedBidID = bid.ID
+		lot.AwardedContractorID = bid.ContractorID
+		if err := tx.Model(&lot).Updates(map[string]interface{}{
+			"awarded_bid_id":        lot.AwardedBidID,
+			"awarded_contractor_id": lot.AwardedContractorID,
+		}).Error; err != nil {
+			return err
+		}
+
+		var lots []model.Lot
+		if err := tx.Where("tender_id = ?", tenderID).Order("id").Find(&lots).Error; err != nil {
+			return err
+		}
+		awards := make([]model.TenderAward, 0, len(lots))
+		for i := range lots {
+			if lots[i].AwardedBidID == 0 {
+				// Wait until every lot has a winner
+				return nil
+			}
+			awards = append(awards, model.TenderAward{
+				TenderID:     tenderID,
+				LotID:        &lots[i].ID,
+				BidID:        lots[i].AwardedBidID,
+				ContractorID: lots[i].AwardedContractorID,
+				Status:       AwardStatusPending,
+				AwardedBy:    clientID,
+			})
+		}
+
+		if err := transitionTender(tx, tender, TenderStatusAwardPending, &clientID, "all lots provisionally awarded"); err != nil {
+			return err
+		}
+
+		bids, err := lockActiveBids(tx, tenderID)
+		if err != nil {
+			return err
+		}
+		for _, bid := range bids {
+			bidIDs = append(bidIDs, bid.ID)
+		}
+
+		queued, err := t.startStandstill(tx, tender, awards, bids, clientID)
+		if err != nil {
+			return err
+		}
+		notifications = queued
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate the caches after awarding the lot
+	t.clearTendersCache()
+	if len(bidIDs) > 0 {
+		t.clearBidsCache(tenderID, bidIDs)
+	}
+
+	t.notifications.Deliver(notifications)
+
+	return &lot, nil
+}
+
+// startStandstill stores the pending awards of a tender that has just moved
+// to award_pending and tells every bidder whether they were selected and
+// until when they may appeal. Without a standstill period the awards are
+// finalized straight away.
+func (t *TenderService) startStandstill(tx *gorm.DB, tender *model.Tender, awards []model.TenderAward, bids []model.Bid, clientID int64) ([]model.Notification, error) {
+	endsAt := time.Now().Add(t.standstill)
+	for i := range awards {
+		awards[i].StandstillEndsAt = endsAt
+	}
+	if err := tx.Create(&awards).Error; err != nil {
+		return nil, fmt.Errorf("failed to record award: %s", err.Error())
+	}
+
+	tender.StandstillEndsAt = &endsAt
+	if err := tx.Model(&model.Tender{}).Where("id = ?", tender.ID).Update("standstill_ends_at", endsAt).Error; err != nil {
+		return nil, err
+	}
+
+	if t.standstill <= 0 {
+		_, notifications, err := finalizeAwards(tx, tender, &clientID, "no standstill period")
+		return notifications, err
+	}
+
+	winners := make(map[int64]bool, len(awards))
+	for _, award := range awards {
+		winners[award.BidID] = true
+	}
+
+	deadline := endsAt.Format(time.RFC3339)
+	var notifications []model.Notification
+	for _, bid := range bids {
+		message := fmt.Sprintf("Your bid #%d on tender #%d %q was not selected. You may appeal the decision until %s.", bid.ID, tender.ID, tender.Title, deadline)
+		if winners[bid.ID] {
+			message = fmt.Sprintf("Your bid #%d on tender #%d %q has been selected. The award becomes final after the standstill period ends at %s.", bid.ID, tender.ID, tender.Title, deadline)
+		}
+
+		queued, err := queueNotifications(tx, []int64{bid.ContractorID}, message)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, queued...)
+	}
+	return notifications, nil
+}
+
+// finalizeAwards makes the pending awards of a tender final: the tender
+// becomes awarded, the winning bids are accepted and every other active bid
+// rejected. It returns the settled bids and the queued notifications.
+func finalizeAwards(tx *gorm.DB, tender *model.Tender, actorID *int64, reason string) ([]model.Bid, []model.Notification, error) {
+	var awards []model.TenderAward
+	if err := tx.Where("tender_id = ? AND status = ?", tender.ID, AwardStatusPending).Find(&awards).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(awards) == 0 {
+		return nil, nil, fmt.Errorf("tender %d has no pending award", tender.ID)
+	}
+
+	if err := transitionTender(tx, tender, TenderStatusAwarded, actorID, reason); err != nil {
+		return nil, nil, err
+	}
+
+	winners := make(map[int64]bool, len(awards))
+	for _, award := range awards {
+		winners[award.BidID] = true
+	}
+
+	// Lots keep their own winners, a single award names the tender's contractor
+	if len(awards) == 1 && awards[0].LotID == nil {
+		tender.AwardedContractorID = awards[0].ContractorID
+		if err := tx.Model(&model.Tender{}).Where("id = ?", tender.ID).
+			Update("awarded_contractor_id", tender.AwardedContractorID).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Model(&model.TenderAward{}).
+		Where("tender_id = ? AND status = ?", tender.ID, AwardStatusPending).
+		Updates(map[string]interface{}{
+			"status":      AwardStatusFinal,
+			"resolved_at": time.Now(),
+		}).Error; err != nil {
+		return nil, nil, err
+	}
+
+	bids, err := lockActiveBids(tx, tender.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	notifications, err := settleBids(tx, tender, bids, winners)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bids, notifications, nil
+}
+
+// settleBids accepts the winning bids, rejects the rest and queues a
+// notification for every bidder about the outcome.
+func settleBids(tx *gorm.DB, tender *model.Tender, bids []model.Bid, winners map[int64]bool) ([]model.Notification, error) {
+	var winnerIDs, loserIDs []int64
+	for _, bid := range bids {
+		if winners[bid.ID] {
+			winnerIDs = append(winnerIDs, bid.ID)
+		} else {
+			loserIDs = append(loserIDs, bid.ID)
+		}
+	}
+
+	if len(winnerIDs) > 0 {
+		if err := tx.Model(&model.Bid{}).Where("id IN ?", winnerIDs).Update("status", "accepted").Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(loserIDs) > 0 {
+		if err := tx.Model(&model.Bid{}).Where("id IN ?", loserIDs).Update("status", "rejected").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var notifications []model.Notification
+	for _, bid := range bids {
+		message := fmt.Sprintf("Your bid #%d on tender #%d %q was not successful.", bid.ID, tender.ID, tender.Title)
+		if winners[bid.ID] {
+			message = fmt.Sprintf("Congratulations! Your bid #%d on tender #%d %q has been awarded.", bid.ID, tender.ID, tender.Title)
+		}
+
+		queued, err := queueNotifications(tx, []int64{bid.ContractorID}, message)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, queued...)
+	}
+
+	return notifications, nil
+}
+
+// FinalizeAwards makes every pending award whose standstill period has ended
+// and that has no open appeal final, and returns the tenders it awarded.
+// Rows are locked with SKIP LOCKED so two replicas never finalize the same
+// tender twice.
+func (t *TenderService) FinalizeAwards(now time.Time) ([]model.Tender, error) {
+	var (
+		tenders       []model.Tender
+		settled       = make(map[int64][]int64)
+		notifications []model.Notification
+	)
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND standstill_ends_at <= ?", TenderStatusAwardPending, now).
+			Where("NOT EXISTS (SELECT 1 FROM tender_appeals WHERE tender_appeals.tender_id = tenders.id AND tender_appeals.status = ?)", AppealStatusOpen).
+			Find(&tenders).Error; err != nil {
+			return err
+		}
+
+		for i := range tenders {
+			bids, queued, err := finalizeAwards(tx, &tenders[i], nil, "standstill period ended")
+			if err != nil {
+				return err
+			}
+			for _, bid := range bids {
+				settled[tenders[i].ID] = append(settled[tenders[i].ID], bid.ID)
+			}
+			notifications = append(notifications, queued...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenders) > 0 {
+		// Invalidate the caches after finalizing awards
+		t.clearTendersCache()
+		for tenderID, bidIDs := range settled {
+			t.clearBidsCache(tenderID, bidIDs)
+		}
+	}
+
+	t.notifications.Deliver(notifications)
+
+	return tenders, nil
+}
+
+// CancelAward cancels the pending award of a tender and sends it back to
+// evaluation, so the client can award it again.
+func (t *TenderService) CancelAward(tenderID, clientID int64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("invalid input: a cancellation reason is required")
+	}
+
+	var notifications []model.Notification
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		tender, err := lockClientTender(tx, tenderID, clientID)
+		if err != nil {
+			return err
+		}
+
+		queued, err := reopenEvaluation(tx, tender, &clientID, "award cancelled: "+reason)
+		if err != nil {
+			return err
+		}
+		notifications = queued
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.clearTendersCache()
+	t.notifications.Deliver(notifications)
+
+	return nil
+}
+
+// reopenEvaluation cancels the pending awards of a tender, moves it back to
+// evaluating and lets the bidders know the decision is open again.
+func reopenEvaluation(tx *gorm.DB, tender *model.Tender, actorID *int64, reason string) ([]model.Notification, error) {
+	if tender.Status != TenderStatusAwardPending {
+		return nil, &InvalidTransitionError{From: tender.Status, To: TenderStatusEvaluating}
+	}
+
+	if err := cancelPendingAwards(tx, tender, reason); err != nil {
+		return nil, err
+	}
+
+	if err := transitionTender(tx, tender, TenderStatusEvaluating, actorID, reason); err != nil {
+		return nil, err
+	}
+
+	var bidderIDs []int64
+	if err := tx.Model(&model.Bid{}).
+		Where("tender_id = ? AND status <> ?", tender.ID, "withdrawn").
+		Distinct().
+		Pluck("contractor_id", &bidderIDs).Error; err != nil {
+		return nil, err
+	}
+
+	return queueNotifications(tx, bidderIDs,
+		fmt.Sprintf("The award of tender #%d %q has been cancelled (%s). The bids are being evaluated again.", tender.ID, tender.Title, reason))
+}
+
+// cancelPendingAwards cancels the pending awards of a tender, clears the
+// provisional lot winners and closes appeals that are no longer relevant.
+func cancelPendingAwards(tx *gorm.DB, tender *model.Tender, reason string) error {
+	now := time.Now()
+
+	if err := tx.Model(&model.TenderAward{}).
+		Where("tender_id = ? AND status = ?", tender.ID, AwardStatusPending).
+		Updates(map[string]interface{}{
+			"status":        AwardStatusCancelled,
+			"cancel_reason": reason,
+			"resolved_at":   now,
+		}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.Lot{}).Where("tender_id = ?", tender.ID).Updates(map[string]interface{}{
+		"awarded_bid_id":        0,
+		"awarded_contractor_id": 0,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&model.TenderAppeal{}).
+		Where("tender_id = ? AND status = ?", tender.ID, AppealStatusOpen).
+		Updates(map[string]interface{}{
+			"status":      AppealStatusClosed,
+			"resolution":  reason,
+			"resolved_at": now,
+		}).Error; err != nil {
+		return err
+	}
+
+	tender.StandstillEndsAt = nil
+	return tx.Model(&model.Tender{}).Where("id = ?", tender.ID).Update("standstill_ends_at", nil).Error
+}
+
+// GetAwards returns every award decision of the client's tender, including
+// cancelled ones, oldest first.
+func (t *TenderService) GetAwards(tenderID, clientID int64) ([]model.TenderAward, error) {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderReadPrivate); err != nil {
+		return nil, err
+	}
+
+	var awards []model.TenderAward
+	if err := t.db.Where("tender_id = ?", tenderID).Order("created_at, id").Find(&awards).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch awards: %s", err.Error())
+	}
+	return awards, nil
+}
+
+// FileAppeal records a bidder's appeal against the pending award of a tender.
+// Appeals are accepted until the standstill period ends, and the award cannot
+// become final while an appeal is open.
+func (t *TenderService) FileAppeal(tenderID, contractorID int64, req *request_model.FileAppealReq) (*model.TenderAppeal, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("invalid input: an appeal reason is required")
+	}
+
+	var (
+		appeal        model.TenderAppeal
+		notifications []model.Notification
+	)
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		var tender model.Tender
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tender, tenderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("Tender not found")
+			}
+			return err
+		}
+
+		if tender.Status != TenderStatusAwardPending {
+			return errors.New("Tender has no pending award to appeal")
+		}
+		if tender.StandstillEndsAt == nil || time.Now().After(*tender.StandstillEndsAt) {
+			return errors.New("The standstill period has ended")
+		}
+
+		bid, err := appellantBid(tx, tenderID, contractorID)
+		if err != nil {
+			return err
+		}
+		if bid == nil {
+			return errors.New("Only bidders on this tender can appeal")
+		}
+
+		var selected int64
+		if err := tx.Model(&model.TenderAward{}).
+			Where("tender_id = ? AND bid_id = ? AND status = ?", tenderID, bid.ID, AwardStatusPending).
+			Count(&selected).Error; err != nil {
+			return err
+		}
+		if selected > 0 {
+			return errors.New("The selected bidder cannot appeal the award")
+		}
+
+		var open int64
+		if err := tx.Model(&model.TenderAppeal{}).
+			Where("tender_id = ? AND (contractor_id = ? OR bid_id = ?) AND status = ?", tenderID, contractorID, bid.ID, AppealStatusOpen).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return errors.New("You already have an open appeal on this tender")
+		}
+
+		appeal = model.TenderAppeal{
+			TenderID:     tenderID,
+			ContractorID: contractorID,
+			BidID:        &bid.ID,
+			Reason:       reason,
+			Status:       AppealStatusOpen,
+		}
+		if err := tx.Create(&appeal).Error; err != nil {
+			return fmt.Errorf("failed to file appeal: %s", err.Error())
+		}
+
+		queued, err := queueNotifications(tx, []int64{tender.ClientID},
+			fmt.Sprintf("A bidder has appealed the award of tender #%d %q (appeal #%d).", tender.ID, tender.Title, appeal.ID))
+		if err != nil {
+			return err
+		}
+		notifications = queued
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.notifications.Deliver(notifications)
+
+	return &appeal, nil
+}
+
+// GetAppeals lists the appeals of a tender. The tender owner and the members
+// of its organization see every appeal, a contractor their own and those
+// filed for the bids of their organizations.
+func (t *TenderService) GetAppeals(tenderID, userID int64) ([]model.TenderAppeal, error) {
+	tender, err := t.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := authorizeTender(t.db, tender, userID, policy.ActionTenderReadPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	query := t.db.Where("tender_id = ?", tenderID)
+	if !owner {
+		query = query.Where("contractor_id = ? OR bid_id IN (?)", userID,
+			t.db.Model(&model.Bid{}).Select("id").Where("organization_id IN (?)", memberOrganizations(t.db, userID)))
+	}
+
+	var appeals []model.TenderAppeal
+	if err := query.Order("created_at, id").Find(&appeals).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch appeals: %s", err.Error())
+	}
+	return appeals, nil
+}
+
+// ResolveAppeal records the tender owner's decision on an open appeal.
+// Upholding an appeal cancels the pending award and sends the tender back to
+// evaluation.
+func (t *TenderService) ResolveAppeal(tenderID, appealID, clientID int64, req *request_model.ResolveAppealReq) (*model.TenderAppeal, error) {
+	return t.resolveAppeal(tenderID, appealID, clientID, req, func(tx *gorm.DB) (*model.Tender, error) {
+		return lockClientTender(tx, tenderID, clientID)
+	})
+}
+
+// resolveAppeal records the decision of the resolver, who may resolve appeals
+// on whichever tenders lock returns.
+func (t *TenderService) resolveAppeal(tenderID, appealID, resolverID int64, req *request_model.ResolveAppealReq, lock func(tx *gorm.DB) (*model.Tender, error)) (*model.TenderAppeal, error) {
+	if req.Decision != AppealStatusUpheld && req.Decision != AppealStatusDismissed {
+		return nil, errors.New("invalid input: decision must be 'upheld' or 'dismissed'")
+	}
+	resolution := strings.TrimSpace(req.Resolution)
+	if resolution == "" {
+		return nil, errors.New("invalid input: a resolution is required")
+	}
+
+	var (
+		appeal        model.TenderAppeal
+		notifications []model.Notification
+	)
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		tender, err := lock(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tender_id = ?", appealID, tenderID).
+			First(&appeal).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("Appeal not found")
+			}
+			return err
+		}
+		if appeal.Status != AppealStatusOpen {
+			return fmt.Errorf("Appeal has already been %s", appeal.Status)
+		}
+
+		now := time.Now()
+		appeal.Status = req.Decision
+		appeal.Resolution = resolution
+		appeal.ResolvedBy = &resolverID
+		appeal.ResolvedAt = &now
+		if err := tx.Model(&model.TenderAppeal{}).Where("id = ?", appeal.ID).Updates(map[string]interface{}{
+			"status":      appeal.Status,
+			"resolution":  appeal.Resolution,
+			"resolved_by": appeal.ResolvedBy,
+			"resolved_at": appeal.ResolvedAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to resolve appeal: %s", err.Error())
+		}
+
+		queued, err := queueNotifications(tx, []int64{appeal.ContractorID},
+			fmt.Sprintf("Your appeal #%d on tender #%d %q was %s: %s", appeal.ID, tender.ID, tender.Title, appeal.Status, resolution))
+		if err != nil {
+			return err
+		}
+		notifications = queued
+
+		if appeal.Status == AppealStatusUpheld {
+			queued, err := reopenEvaluation(tx, tender, &resolverID, fmt.Sprintf("appeal #%d upheld: %s", appeal.ID, resolution))
+			if err != nil {
+				return err
+			}
+			notifications = append(notifications, queued...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if appeal.Status == AppealStatusUpheld {
+		t.clearTendersCache()
+	}
+	t.notifications.Deliver(notifications)
+
+	return &appeal, nil
+}
+
+// appellantBid returns the active bid on the tender the contractor may appeal
+// for: their own, or the bid of an organization the policy lets them act for.
+// Their own bid comes first.
+func appellantBid(tx *gorm.DB, tenderID, contractorID int64) (*model.Bid, error) {
+	var bids []model.Bid
+	if err := tx.Where("tender_id = ? AND status IN ? AND (contractor_id = ? OR organization_id IN (?))",
+		tenderID, []string{"pending", "accepted"}, contractorID, memberOrganizations(tx, contractorID)).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "contractor_id = ? DESC, id", Vars: []interface{}{contractorID}, WithoutParentheses: true}}).
+		Find(&bids).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing bids: %s", err.Error())
+	}
+
+	for i := range bids {
+		allowed, err := authorizeBid(tx, &bids[i], contractorID, policy.ActionAppealFile)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			return &bids[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// lockClientTender locks a tender the client may award for the rest of the
+// transaction.
+func lockClientTender(tx *gorm.DB, tenderID, clientID int64) (*model.Tender, error) {
+	tender, err := lockTender(tx, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := authorizeTender(tx, tender, clientID, policy.ActionTenderAward)
+	if err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, errors.New("Tender not found or access denied")
+	}
+	return tender, nil
+}
+
+// lockTender locks any tender for the rest of the transaction.
+func lockTender(tx *gorm.DB, tenderID int64) (*model.Tender, error) {
+	var tender model.Tender
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tender, tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Tender not found or access denied")
+		}
+		return nil, err
+	}
+	return &tender, nil
+}
+
+// lockActiveBids locks every bid of the tender that has not been withdrawn.
+func lockActiveBids(tx *gorm.DB, tenderID int64) ([]model.Bid, error) {
+	var bids []model.Bid
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tender_id = ? AND status <> ?", tenderID, "withdrawn").
+		Find(&bids).Error; err != nil {
+		return nil, err
+	}
+	return bids, nil
+}
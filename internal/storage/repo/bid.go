@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/internal/pkg/policy"
 	"tender-backend/model"
 	request_model "tender-backend/model/request"
 )
@@ -17,6 +21,7 @@ type BidService struct {
 	db            *gorm.DB
 	tenderService *TenderService
 	redis         *redis.Client
+	now           func() time.Time
 }
 
 func NewBidService(db *gorm.DB, redisClient *redis.Client) *BidService {
@@ -24,36 +29,102 @@ func NewBidService(db *gorm.DB, redisClient *redis.Client) *BidService {
 		db:            db,
 		tenderService: NewTenderService(db, redisClient),
 		redis:         redisClient,
+		now:           time.Now,
 	}
 }
 
+// SetClock replaces the clock used to check bid deadlines.
+func (s *BidService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
 func (s *BidService) CreateBid(req *request_model.CreateBidReq, tenderID int64, contractorID int64) (*model.Bid, error) {
+	// The price of a multi-lot bid is the total of its lot prices
+	if len(req.Lots) > 0 {
+		req.Price = 0
+		for _, lot := range req.Lots {
+			req.Price += lot.Price
+		}
+	}
+
 	if err := s.validateCreateBidRequest(req); err != nil {
 		return nil, err
 	}
+	if err := checkActingOrganization(s.db, req.OrganizationID, contractorID); err != nil {
+		return nil, err
+	}
 
 	var tender model.Tender
-	if err := s.db.First(&tender, tenderID).Error; err != nil {
+	if err := s.db.Preload("Lots").First(&tender, tenderID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("Tender not found")
 		}
 		return nil, fmt.Errorf("failed to fetch tender: %s", err.Error())
 	}
 
-	if tender.Status != "open" {
+	// Invite-only tenders look missing to contractors that were not invited
+	allowed, err := canAccessTender(s.db, &tender, contractorID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("Tender not found")
+	}
+
+	submittedAt := s.now()
+	cutoff := tender.Deadline.Add(time.Duration(tender.GracePeriodMinutes) * time.Minute)
+	if submittedAt.After(cutoff) {
+		s.recordLateBid(&tender, contractorID, req.Price, submittedAt)
+		return nil, &LateBidError{
+			TenderID:    tender.ID,
+			Deadline:    tender.Deadline,
+			SubmittedAt: submittedAt,
+		}
+	}
+
+	if tender.Status != TenderStatusOpen {
 		return nil, errors.New("Tender is not open for bids")
 	}
 
+	if tender.Auction {
+		return nil, errors.New("Bids on an auction must be placed in the live auction")
+	}
+
+	if err := validateBidLots(&tender, req.Lots); err != nil {
+		return nil, err
+	}
+
+	if err := checkActiveBid(s.db, tenderID, contractorID); err != nil {
+		return nil, err
+	}
+	if err := checkActiveOrganizationBid(s.db, tenderID, req.OrganizationID); err != nil {
+		return nil, err
+	}
+
 	newBid := model.Bid{
-		TenderID:     tenderID,
-		ContractorID: contractorID,
-		Price:        req.Price,
-		DeliveryTime: req.DeliveryTime,
-		Comments:     req.Comments,
-		Status:       "pending",
+		TenderID:       tenderID,
+		ContractorID:   contractorID,
+		OrganizationID: req.OrganizationID,
+		Price:         req.Price,
+		DeliveryTime:  req.DeliveryTime,
+		Comments:      req.Comments,
+		Status:        "pending",
+		TenderVersion: tender.Version,
+	}
+	for _, lot := range req.Lots {
+		newBid.Lots = append(newBid.Lots, model.BidLot{LotID: lot.LotID, Price: lot.Price})
 	}
 
 	if err := s.db.Create(&newBid).Error; err != nil {
+		// A concurrent submission won the race for the unique index
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if dupErr := checkActiveBid(s.db, tenderID, contractorID); dupErr != nil {
+				return nil, dupErr
+			}
+			if dupErr := checkActiveOrganizationBid(s.db, tenderID, req.OrganizationID); dupErr != nil {
+				return nil, dupErr
+			}
+		}
 		return nil, fmt.Errorf("failed to create bid: %s", err.Error())
 	}
 
@@ -62,7 +133,135 @@ func (s *BidService) CreateBid(req *request_model.CreateBidReq, tenderID int64,
 	return &newBid, nil
 }
 
-func (s *BidService) GetBidByID(bidID, tenderID int64) (*model.Bid, error) {
+// checkActiveBid returns a DuplicateBidError when the contractor already has
+// an active bid on the tender.
+func checkActiveBid(tx *gorm.DB, tenderID, contractorID int64) error {
+	active, err := activeBid(tx, tenderID, contractorID)
+	if err != nil {
+		return err
+	}
+	if active != nil {
+		return &DuplicateBidError{TenderID: tenderID, BidID: active.ID}
+	}
+	return nil
+}
+
+// checkActiveOrganizationBid returns a DuplicateBidError when a colleague
+// already placed an active bid on the tender for the organization.
+func checkActiveOrganizationBid(tx *gorm.DB, tenderID int64, orgID *int64) error {
+	if orgID == nil {
+		return nil
+	}
+
+	var bid model.Bid
+	err := tx.Where("tender_id = ? AND organization_id = ? AND status IN ?", tenderID, *orgID, []string{"pending", "accepted"}).
+		First(&bid).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check existing bids: %s", err.Error())
+	}
+	return &DuplicateBidError{TenderID: tenderID, BidID: bid.ID}
+}
+
+// activeBid returns the contractor's pending or accepted bid on the tender, or
+// nil when there is none.
+func activeBid(tx *gorm.DB, tenderID, contractorID int64) (*model.Bid, error) {
+	var bid model.Bid
+	err := tx.Where("tender_id = ? AND contractor_id = ? AND status IN ?", tenderID, contractorID, []string{"pending", "accepted"}).
+		First(&bid).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing bids: %s", err.Error())
+	}
+	return &bid, nil
+}
+
+// validateBidLots checks that a bid on a multi-lot tender names at least one
+// lot of that tender, each at most once and with a positive price.
+func validateBidLots(tender *model.Tender, lots []request_model.BidLotReq) error {
+	if len(tender.Lots) == 0 {
+		if len(lots) > 0 {
+			return errors.New("Tender has no lots")
+		}
+		return nil
+	}
+
+	if len(lots) == 0 {
+		return errors.New("Bid must target at least one lot")
+	}
+
+	tenderLots := make(map[int64]bool, len(tender.Lots))
+	for _, lot := range tender.Lots {
+		tenderLots[lot.ID] = true
+	}
+
+	seen := make(map[int64]bool, len(lots))
+	for _, lot := range lots {
+		if !tenderLots[lot.LotID] {
+			return fmt.Errorf("Lot %d does not belong to this tender", lot.LotID)
+		}
+		if seen[lot.LotID] {
+			return fmt.Errorf("Lot %d is listed more than once", lot.LotID)
+		}
+		if lot.Price <= 0 {
+			return errors.New("invalid price")
+		}
+		seen[lot.LotID] = true
+	}
+
+	return nil
+}
+
+// recordLateBid keeps an audit trail of rejected late submissions.
+func (s *BidService) recordLateBid(tender *model.Tender, contractorID int64, price float64, submittedAt time.Time) {
+	attempt := model.LateBidAttempt{
+		TenderID:     tender.ID,
+		ContractorID: contractorID,
+		Price:        price,
+		Deadline:     tender.Deadline,
+		AttemptedAt:  submittedAt,
+	}
+	if err := s.db.Create(&attempt).Error; err != nil {
+		log.Printf("failed to record late bid attempt for tender %d: %v", tender.ID, err)
+	}
+}
+
+// GetBidByID returns a bid to those the policy lets read it: its bidder and
+// the owner of its tender.
+func (s *BidService) GetBidByID(bidID, tenderID, userID int64) (*model.Bid, error) {
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.isSealed(tender)
+	if err != nil {
+		return nil, err
+	}
+
+	bid, err := s.getBidByID(bidID, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := authorizeBid(s.db, bid, userID, policy.ActionBidRead)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.New("Bid not found or access denied")
+	}
+
+	if sealed {
+		redactBid(bid)
+	}
+	return bid, nil
+}
+
+func (s *BidService) getBidByID(bidID, tenderID int64) (*model.Bid, error) {
 	ctx := context.Background()
 	cacheKey := fmt.Sprintf("bid_%d_tender_%d", bidID, tenderID)
 
@@ -75,7 +274,7 @@ func (s *BidService) GetBidByID(bidID, tenderID int64) (*model.Bid, error) {
 	}
 
 	var bid model.Bid
-	if err := s.db.Where("id = ? AND tender_id = ?", bidID, tenderID).First(&bid).Error; err != nil {
+	if err := s.db.Preload("Lots").Where("id = ? AND tender_id = ?", bidID, tenderID).First(&bid).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("bid not found")
 		}
@@ -88,12 +287,37 @@ func (s *BidService) GetBidByID(bidID, tenderID int64) (*model.Bid, error) {
 	return &bid, nil
 }
 
-func (s *BidService) GetAllBids(tenderID int64) ([]model.Bid, error) {
-	// Ensure the tender exists before fetching bids
-	if _, err := s.tenderService.GetTenderById(tenderID); err != nil {
+// GetAllBids returns the bids of a tender to those the policy lets read its
+// private details.
+func (s *BidService) GetAllBids(tenderID, clientID int64) ([]model.Bid, error) {
+	if err := s.tenderService.AuthorizeTender(tenderID, clientID, policy.ActionTenderReadPrivate); err != nil {
+		return nil, err
+	}
+
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.isSealed(tender)
+	if err != nil {
 		return nil, err
 	}
 
+	bids, err := s.getAllBids(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sealed {
+		for i := range bids {
+			redactBid(&bids[i])
+		}
+	}
+	return bids, nil
+}
+
+func (s *BidService) getAllBids(tenderID int64) ([]model.Bid, error) {
 	ctx := context.Background()
 	cacheKey := fmt.Sprintf("bids_tender_%d", tenderID)
 
@@ -106,7 +330,7 @@ func (s *BidService) GetAllBids(tenderID int64) ([]model.Bid, error) {
 	}
 
 	var bids []model.Bid
-	if err := s.db.Where("tender_id = ?", tenderID).Find(&bids).Error; err != nil {
+	if err := s.db.Preload("Lots").Where("tender_id = ?", tenderID).Find(&bids).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch bids: %s", err.Error())
 	}
 
@@ -116,6 +340,36 @@ func (s *BidService) GetAllBids(tenderID int64) ([]model.Bid, error) {
 	return bids, nil
 }
 
+// isSealed reports whether the bids of the tender must still be hidden, which
+// is the case for sealed tenders until their bid opening has been recorded.
+func (s *BidService) isSealed(tender *model.Tender) (bool, error) {
+	return bidsSealed(s.db, tender)
+}
+
+func bidsSealed(tx *gorm.DB, tender *model.Tender) (bool, error) {
+	if !tender.Sealed {
+		return false, nil
+	}
+
+	var openings int64
+	if err := tx.Model(&model.BidOpening{}).Where("tender_id = ?", tender.ID).Count(&openings).Error; err != nil {
+		return false, fmt.Errorf("failed to check bid opening: %s", err.Error())
+	}
+	return openings == 0, nil
+}
+
+// redactBid hides everything but the existence of a bid.
+func redactBid(bid *model.Bid) {
+	bid.Price = 0
+	bid.DeliveryTime = 0
+	bid.Comments = ""
+	bid.WithdrawalReason = ""
+	bid.Redacted = true
+	for i := range bid.Lots {
+		bid.Lots[i].Price = 0
+	}
+}
+
 func (s *BidService) validateCreateBidRequest(req *request_model.CreateBidReq) error {
 	if req.DeliveryTime <= 0 {
 		return errors.New("Invalid bid data")
@@ -128,30 +382,86 @@ func (s *BidService) validateCreateBidRequest(req *request_model.CreateBidReq) e
 	return nil
 }
 
+// GetContractorBids returns the contractor's bids and those of the
+// organizations they belong to.
 func (s *BidService) GetContractorBids(contractorID int64) ([]model.Bid, error) {
 	var bids []model.Bid
-	if err := s.db.Where("contractor_id = ?", contractorID).Find(&bids).Error; err != nil {
+	if err := s.db.Preload("Lots").
+		Where("contractor_id = ? OR organization_id IN (?)", contractorID, memberOrganizations(s.db, contractorID)).
+		Find(&bids).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve bids: %s", err.Error())
 	}
 
 	return bids, nil
 }
 
-func (s *BidService) DeleteBid(bidID, contractorID int64) error {
+// WithdrawBid withdraws a pending bid while its tender is still open. The bid
+// is kept with its reason and timestamp so the client can still see it in the
+// tender's bid list, but it no longer takes part in ranking or award.
+func (s *BidService) WithdrawBid(bidID, contractorID int64, reason string) (*model.Bid, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("invalid input: a withdrawal reason is required")
+	}
+
 	var bid model.Bid
-	if err := s.db.Where("id = ? AND contractor_id = ?", bidID, contractorID).First(&bid).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockContractorBid(tx, &bid, bidID, contractorID); err != nil {
+			return err
+		}
+
+		if bid.Status != "pending" {
+			return fmt.Errorf("Bid cannot be withdrawn: it is %s", bid.Status)
+		}
+
+		var tender model.Tender
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&tender, bid.TenderID).Error; err != nil {
+			return fmt.Errorf("failed to fetch tender: %s", err.Error())
+		}
+		if tender.Status != TenderStatusOpen {
+			return errors.New("Tender is not open for bids")
+		}
+
+		withdrawnAt := s.now()
+		bid.Status = "withdrawn"
+		bid.WithdrawnAt = &withdrawnAt
+		bid.WithdrawalReason = reason
+		if err := tx.Model(&model.Bid{}).Where("id = ?", bid.ID).Updates(map[string]interface{}{
+			"status":            bid.Status,
+			"withdrawn_at":      bid.WithdrawnAt,
+			"withdrawal_reason": bid.WithdrawalReason,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to withdraw bid: %s", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear related cache after withdrawing a bid
+	s.clearBidsCache(bid.TenderID)
+	_ = s.redis.Del(context.Background(), fmt.Sprintf("bid_%d_tender_%d", bid.ID, bid.TenderID)).Err()
+	return &bid, nil
+}
+
+// lockContractorBid locks a bid with its lots for the rest of the transaction
+// when the policy lets the contractor update it.
+func lockContractorBid(tx *gorm.DB, bid *model.Bid, bidID, contractorID int64) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lots").First(bid, bidID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("Bid not found or access denied")
 		}
 		return fmt.Errorf("failed to find bid: %s", err.Error())
 	}
 
-	if err := s.db.Delete(&bid).Error; err != nil {
-		return fmt.Errorf("failed to delete bid: %s", err.Error())
+	owner, err := authorizeBid(tx, bid, contractorID, policy.ActionBidUpdate)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return errors.New("Bid not found or access denied")
 	}
-
-	// Clear related cache after deleting a bid
-	s.clearBidsCache(bid.TenderID)
 	return nil
 }
 
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+// ReviseBid replaces the terms of a bid while its tender is still accepting
+// bids. The previous terms are kept in the bid_revisions table and the bid is
+// moved onto the current tender version.
+func (s *BidService) ReviseBid(bidID, contractorID int64, req *request_model.CreateBidReq) (*model.Bid, error) {
+	// The price of a multi-lot bid is the total of its lot prices
+	if len(req.Lots) > 0 {
+		req.Price = 0
+		for _, lot := range req.Lots {
+			req.Price += lot.Price
+		}
+	}
+
+	if err := s.validateCreateBidRequest(req); err != nil {
+		return nil, err
+	}
+
+	var (
+		bid     model.Bid
+		tender  model.Tender
+		lateErr *LateBidError
+	)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockContractorBid(tx, &bid, bidID, contractorID); err != nil {
+			return err
+		}
+
+		if bid.Status != "pending" {
+			return fmt.Errorf("Bid cannot be revised: it is %s", bid.Status)
+		}
+
+		if err := tx.Preload("Lots").First(&tender, bid.TenderID).Error; err != nil {
+			return fmt.Errorf("failed to fetch tender: %s", err.Error())
+		}
+
+		submittedAt := s.now()
+		cutoff := tender.Deadline.Add(time.Duration(tender.GracePeriodMinutes) * time.Minute)
+		if submittedAt.After(cutoff) {
+			lateErr = &LateBidError{TenderID: tender.ID, Deadline: tender.Deadline, SubmittedAt: submittedAt}
+			return lateErr
+		}
+
+		if tender.Status != TenderStatusOpen {
+			return errors.New("Tender is not open for bids")
+		}
+		if tender.Auction {
+			return errors.New("Auction bids cannot be revised")
+		}
+
+		if err := validateBidLots(&tender, req.Lots); err != nil {
+			return err
+		}
+
+		revision := model.BidRevision{
+			BidID:         bid.ID,
+			Revision:      bid.Revision,
+			TenderVersion: bid.TenderVersion,
+			Price:         bid.Price,
+			DeliveryTime:  bid.DeliveryTime,
+			Comments:      bid.Comments,
+			ReplacedAt:    submittedAt,
+		}
+		for _, lot := range bid.Lots {
+			revision.Lots = append(revision.Lots, model.RevisedBidLot{LotID: lot.LotID, Price: lot.Price})
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return fmt.Errorf("failed to store bid revision: %s", err.Error())
+		}
+
+		bid.Price = req.Price
+		bid.DeliveryTime = req.DeliveryTime
+		bid.Comments = req.Comments
+		bid.TenderVersion = tender.Version
+		bid.Revision++
+		if err := tx.Model(&model.Bid{}).Where("id = ?", bid.ID).Updates(map[string]interface{}{
+			"price":          bid.Price,
+			"delivery_time":  bid.DeliveryTime,
+			"comments":       bid.Comments,
+			"tender_version": bid.TenderVersion,
+			"revision":       bid.Revision,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update bid: %s", err.Error())
+		}
+
+		if err := tx.Where("bid_id = ?", bid.ID).Delete(&model.BidLot{}).Error; err != nil {
+			return fmt.Errorf("failed to update bid lots: %s", err.Error())
+		}
+		bid.Lots = nil
+		for _, lot := range req.Lots {
+			bid.Lots = append(bid.Lots, model.BidLot{BidID: bid.ID, LotID: lot.LotID, Price: lot.Price})
+		}
+		if len(bid.Lots) > 0 {
+			if err := tx.Create(&bid.Lots).Error; err != nil {
+				return fmt.Errorf("failed to update bid lots: %s", err.Error())
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if lateErr != nil {
+			s.recordLateBid(&tender, contractorID, req.Price, lateErr.SubmittedAt)
+		}
+		return nil, err
+	}
+
+	// Clear related cache after revising a bid
+	s.clearBidsCache(bid.TenderID)
+	_ = s.redis.Del(context.Background(), fmt.Sprintf("bid_%d_tender_%d", bid.ID, bid.TenderID)).Err()
+
+	return &bid, nil
+}
+
+// GetBidRevisions returns the previous versions of a bid to its contractor and
+// the members of its organization.
+func (s *BidService) GetBidRevisions(bidID, contractorID int64) ([]model.BidRevision, error) {
+	var bid model.Bid
+	if err := s.db.First(&bid, bidID).Error; err != nil {
+		return nil, errors.New("Bid not found or access denied")
+	}
+	owner, err := authorizeBid(s.db, &bid, contractorID, policy.ActionBidRevisions)
+	if err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, errors.New("Bid not found or access denied")
+	}
+
+	return s.bidRevisions(bidID)
+}
+
+// GetBidRevisionsForClient returns the previous versions of a bid to the
+// tender owner. The history only becomes visible once the deadline has passed
+// and, for sealed tenders, the bids have been opened.
+func (s *BidService) GetBidRevisionsForClient(tenderID, bidID, clientID int64) ([]model.BidRevision, error) {
+	if err := s.tenderService.AuthorizeTender(tenderID, clientID, policy.ActionTenderReadPrivate); err != nil {
+		return nil, err
+	}
+
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.now().After(tender.Deadline) {
+		return nil, errors.New("Bid history is available after the deadline")
+	}
+
+	sealed, err := s.isSealed(tender)
+	if err != nil {
+		return nil, err
+	}
+	if sealed {
+		return nil, errors.New("Bid history is available after the bid opening")
+	}
+
+	if err := s.IsBidExists(bidID, tenderID); err != nil {
+		return nil, err
+	}
+
+	return s.bidRevisions(bidID)
+}
+
+func (s *BidService) bidRevisions(bidID int64) ([]model.BidRevision, error) {
+	var revisions []model.BidRevision
+	if err := s.db.Where("bid_id = ?", bidID).Order("revision").Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch bid revisions: %s", err.Error())
+	}
+	return revisions, nil
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// LateBidError is returned when a bid arrives after the tender deadline and
+// its grace period.
+type LateBidError struct {
+	TenderID    int64
+	Deadline    time.Time
+	SubmittedAt time.Time
+}
+
+func (e *LateBidError) Error() string {
+	return fmt.Sprintf("bid submitted after the deadline of tender %d (deadline %s)",
+		e.TenderID, e.Deadline.Format(time.RFC3339))
+}
+
+// InvalidTransitionError is returned when a tender status change is not
+// allowed by the tender lifecycle.
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("tender status cannot change from '%s' to '%s'", e.From, e.To)
+}
+
+// DuplicateBidError is returned when a contractor submits a second bid on a
+// tender while their earlier bid is still active.
+type DuplicateBidError struct {
+	TenderID int64
+	BidID    int64
+}
+
+func (e *DuplicateBidError) Error() string {
+	return fmt.Sprintf("an active bid %d already exists on tender %d; revise it instead", e.BidID, e.TenderID)
+}
+
+// SealedTenderError is returned when a sealed tender is closed by hand before
+// its deadline and grace period have passed, which would open its bids early.
+type SealedTenderError struct {
+	TenderID int64
+	OpensAt  time.Time
+}
+
+func (e *SealedTenderError) Error() string {
+	return fmt.Sprintf("sealed tender %d cannot be closed before its bids open at %s",
+		e.TenderID, e.OpensAt.Format(time.RFC3339))
+}
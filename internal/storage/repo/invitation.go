@@ -0,0 +1,226 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+const (
+	TenderVisibilityPublic     = "public"
+	TenderVisibilityInviteOnly = "invite_only"
+)
+
+// InviteContractors invites contractors, given by user ID or email, to an
+// invite-only tender and notifies each of them. Contractors that were already
+// invited are skipped.
+func (t *TenderService) InviteContractors(tenderID, clientID int64, req *request_model.InviteContractorsReq) ([]model.TenderInvitation, error) {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderInvite); err != nil {
+		return nil, err
+	}
+	tender, err := t.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if tender.Visibility != TenderVisibilityInviteOnly {
+		return nil, errors.New("invalid input: only invite-only tenders take invitations")
+	}
+
+	contractorIDs, err := t.resolveContractors(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		invitations   []model.TenderInvitation
+		notifications []model.Notification
+	)
+	err = t.db.Transaction(func(tx *gorm.DB) error {
+		var existing []int64
+		if err := tx.Model(&model.TenderInvitation{}).
+			Where("tender_id = ? AND contractor_id IN ?", tenderID, contractorIDs).
+			Pluck("contractor_id", &existing).Error; err != nil {
+			return fmt.Errorf("failed to fetch invitations: %s", err.Error())
+		}
+
+		var invited []int64
+		for _, contractorID := range contractorIDs {
+			if containsID(existing, contractorID) {
+				continue
+			}
+			invited = append(invited, contractorID)
+			invitations = append(invitations, model.TenderInvitation{
+				TenderID:     tenderID,
+				ContractorID: contractorID,
+				InvitedBy:    clientID,
+			})
+		}
+		if len(invitations) == 0 {
+			return nil
+		}
+
+		// A concurrent request may have invited the same contractor meanwhile
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&invitations).Error; err != nil {
+			return fmt.Errorf("failed to create invitations: %s", err.Error())
+		}
+
+		queued, err := queueNotifications(tx, invited,
+			fmt.Sprintf("You have been invited to bid on tender #%d %q.", tender.ID, tender.Title))
+		if err != nil {
+			return err
+		}
+		notifications = queued
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invitees now see the tender in their listings
+	t.clearTendersCache()
+	t.notifications.Deliver(notifications)
+
+	return t.GetInvitations(tenderID, clientID)
+}
+
+// resolveContractors turns the IDs and emails of an invitation request into a
+// de-duplicated list of contractor IDs.
+func (t *TenderService) resolveContractors(req *request_model.InviteContractorsReq) ([]int64, error) {
+	if len(req.ContractorIDs) == 0 && len(req.Emails) == 0 {
+		return nil, errors.New("invalid input: no contractors given")
+	}
+
+	var emails []string
+	for _, email := range req.Emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			emails = append(emails, email)
+		}
+	}
+
+	var users []model.User
+	query := t.db.Where("role = ?", "contractor")
+	switch {
+	case len(req.ContractorIDs) > 0 && len(emails) > 0:
+		query = query.Where("id IN ? OR LOWER(email) IN ?", req.ContractorIDs, emails)
+	case len(req.ContractorIDs) > 0:
+		query = query.Where("id IN ?", req.ContractorIDs)
+	default:
+		query = query.Where("LOWER(email) IN ?", emails)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to find contractors: %s", err.Error())
+	}
+
+	byID := make(map[int64]bool, len(users))
+	byEmail := make(map[string]bool, len(users))
+	contractorIDs := make([]int64, 0, len(users))
+	for _, user := range users {
+		byID[user.ID] = true
+		byEmail[strings.ToLower(user.Email)] = true
+		contractorIDs = append(contractorIDs, user.ID)
+	}
+
+	for _, id := range req.ContractorIDs {
+		if !byID[id] {
+			return nil, fmt.Errorf("invalid input: user %d is not a contractor", id)
+		}
+	}
+	for _, email := range emails {
+		if !byEmail[email] {
+			return nil, fmt.Errorf("invalid input: no contractor is registered with %s", email)
+		}
+	}
+
+	return contractorIDs, nil
+}
+
+// GetInvitations lists the contractors invited to the client's tender.
+func (t *TenderService) GetInvitations(tenderID, clientID int64) ([]model.TenderInvitation, error) {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderReadPrivate); err != nil {
+		return nil, err
+	}
+
+	var invitations []model.TenderInvitation
+	if err := t.db.Where("tender_id = ?", tenderID).Order("created_at, id").Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %s", err.Error())
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation removes a contractor's invitation. Bids already placed by
+// the contractor are kept.
+func (t *TenderService) RevokeInvitation(tenderID, clientID, contractorID int64) error {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderInvite); err != nil {
+		return err
+	}
+
+	result := t.db.Where("tender_id = ? AND contractor_id = ?", tenderID, contractorID).Delete(&model.TenderInvitation{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke invitation: %s", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("Invitation not found")
+	}
+
+	t.clearTendersCache()
+	return nil
+}
+
+// CanViewTender returns the same error as a missing tender when the user may
+// not see it, so drafts and invite-only tenders do not leak their existence.
+func (t *TenderService) CanViewTender(tender *model.Tender, userID int64) error {
+	allowed, err := canAccessTender(t.db, tender, userID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("Tender not found or access denied")
+	}
+	return nil
+}
+
+// canAccessTender reports whether the user may see and bid on the tender.
+// Drafts are only open to their owner and the members of its organization,
+// invite-only tenders to invitees as well, and public tenders to everyone.
+func canAccessTender(tx *gorm.DB, tender *model.Tender, userID int64) (bool, error) {
+	draft := tender.Status == TenderStatusDraft
+	if !draft && tender.Visibility != TenderVisibilityInviteOnly {
+		return true, nil
+	}
+	if userID == 0 {
+		return false, nil
+	}
+	if tender.ClientID == userID {
+		return true, nil
+	}
+
+	members, err := organizationRelations(tx, tender.OrganizationID, userID, "")
+	if err != nil || len(members) > 0 || draft {
+		return len(members) > 0, err
+	}
+
+	var invitations int64
+	if err := tx.Model(&model.TenderInvitation{}).
+		Where("tender_id = ? AND contractor_id = ?", tender.ID, userID).
+		Count(&invitations).Error; err != nil {
+		return false, fmt.Errorf("failed to check invitation: %s", err.Error())
+	}
+	return invitations > 0, nil
+}
+
+// visibleTenders limits a tenders query to the rows the user may see.
+func visibleTenders(query *gorm.DB, userID int64) *gorm.DB {
+	if userID == 0 {
+		return query.Where("tenders.visibility = ? AND tenders.status <> ?", TenderVisibilityPublic, TenderStatusDraft)
+	}
+	return query.Where(
+		"(tenders.status <> ? AND (tenders.visibility = ? OR tenders.id IN (SELECT tender_id FROM tender_invitations WHERE contractor_id = ?))) OR tenders.client_id = ? OR tenders.organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)",
+		TenderStatusDraft, TenderVisibilityPublic, userID, userID, userID,
+	)
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"tender-backend/internal/pkg/config"
+	"tender-backend/internal/pkg/totp"
+	"tender-backend/model"
+	response_model "tender-backend/model/response"
+)
+
+const (
+	totpEnrollmentTTL = 10 * time.Minute // To scan the QR code and confirm it
+	mfaChallengeTTL   = 5 * time.Minute  // Between the password and the code
+	maxMFAAttempts    = 5                // Wrong codes before a challenge is dropped or the user locked out
+	mfaLockout        = 15 * time.Minute // How long wrong codes count against the user
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidMFACode      = errors.New("Invalid authentication code")
+	ErrInvalidMFAChallenge = errors.New("The login attempt is invalid or has expired")
+	ErrTooManyMFAAttempts  = errors.New("Too many wrong authentication codes, try again later")
+)
+
+// MFAService manages TOTP two-factor authentication: enrollment, recovery
+// codes and the second step of the login.
+type MFAService struct {
+	db     *gorm.DB
+	redis  *redis.Client
+	issuer string
+}
+
+func NewMFAService(db *gorm.DB, redisClient *redis.Client, issuer string) *MFAService {
+	return &MFAService{
+		db:     db,
+		redis:  redisClient,
+		issuer: issuer,
+	}
+}
+
+// BeginTOTPEnrollment creates a secret for the user's authenticator app. It
+// only takes effect once ConfirmTOTPEnrollment receives a code generated
+// from it.
+func (s *MFAService) BeginTOTPEnrollment(ctx context.Context, userID int64) (*response_model.TOTPEnrollmentRes, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errors.New("Two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.Set(ctx, totpEnrollmentKey(userID), secret, totpEnrollmentTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store enrollment: %s", err.Error())
+	}
+
+	return &response_model.TOTPEnrollmentRes{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables two-factor authentication once the user
+// proves their app generates valid codes, and returns their recovery codes.
+func (s *MFAService) ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) ([]string, error) {
+	secret, err := s.redis.Get(ctx, totpEnrollmentKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.New("invalid input: no enrollment in progress, or it has expired")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enrollment: %s", err.Error())
+	}
+
+	if err := s.limitAttempts(ctx, userID, func() error {
+		return s.checkTOTP(ctx, userID, secret, code)
+	}); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND totp_enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled_at": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to enable two-factor authentication: %s", result.Error.Error())
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("Two-factor authentication is already enabled")
+		}
+
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.redis.Del(ctx, totpEnrollmentKey(userID))
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking a code, and
+// drops the user's recovery codes.
+func (s *MFAService) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return errors.New("Two-factor authentication is not enabled")
+	}
+	if err := s.verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_secret": "", "totp_enabled_at": nil}).Error; err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %s", err.Error())
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %s", err.Error())
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user, used or
+// not, after checking a code.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, errors.New("Two-factor authentication is not enabled")
+	}
+	if err := s.verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// StartChallenge is the first step of a login with two-factor
+// authentication: the password was right, and the returned token has to be
+// sent back with a code.
+func (s *MFAService) StartChallenge(ctx context.Context, userID int64) (string, time.Time, error) {
+	challenge, err := config.RandomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := s.redis.Set(ctx, mfaChallengeKey(challenge), userID, mfaChallengeTTL).Err(); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store challenge: %s", err.Error())
+	}
+	return challenge, time.Now().Add(mfaChallengeTTL), nil
+}
+
+// CompleteChallenge is the second step of the login. It returns the user once
+// the code is right; after too many wrong codes the challenge is dropped and
+// the login has to start over.
+func (s *MFAService) CompleteChallenge(ctx context.Context, challenge, code string) (*model.User, error) {
+	key := mfaChallengeKey(challenge)
+	userID, err := s.redis.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read challenge: %s", err.Error())
+	}
+
+	attempts, err := s.redis.Incr(ctx, key+"_attempts").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attempts: %s", err.Error())
+	}
+	s.redis.Expire(ctx, key+"_attempts", mfaChallengeTTL)
+	if attempts > maxMFAAttempts {
+		s.redis.Del(ctx, key)
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	// The challenge is single-use
+	deleted, err := s.redis.Del(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete challenge: %s", err.Error())
+	}
+	if deleted == 0 {
+		return nil, ErrInvalidMFAChallenge
+	}
+	return user, nil
+}
+
+// verify accepts either a TOTP code or an unused recovery code.
+func (s *MFAService) verify(ctx context.Context, user *model.User, code string) error {
+	return s.limitAttempts(ctx, user.ID, func() error {
+		return s.checkCode(ctx, user, code)
+	})
+}
+
+// limitAttempts runs a code check and counts it against the user. The count
+// is shared by every check, so guesses cannot be spread over the login and
+// the account endpoints; after maxMFAAttempts checks without a right code all
+// of them fail until mfaLockout has passed since the first.
+func (s *MFAService) limitAttempts(ctx context.Context, userID int64, check func() error) error {
+	key := fmt.Sprintf("mfa_attempts_%d", userID)
+	attempts, err := s.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to count attempts: %s", err.Error())
+	}
+	if attempts == 1 {
+		s.redis.Expire(ctx, key, mfaLockout)
+	}
+	if attempts > maxMFAAttempts {
+		return ErrTooManyMFAAttempts
+	}
+
+	if err := check(); err != nil {
+		return err
+	}
+	s.redis.Del(ctx, key)
+	return nil
+}
+
+func (s *MFAService) checkCode(ctx context.Context, user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if _, err := strconv.Atoi(code); err == nil && len(code) == totp.Digits {
+		return s.checkTOTP(ctx, user.ID, user.TOTPSecret, code)
+	}
+
+	result := s.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to check recovery code: %s", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// checkTOTP validates a TOTP code and rejects it when it was already used,
+// so an observed code cannot be replayed while it is still valid.
+func (s *MFAService) checkTOTP(ctx context.Context, userID int64, secret, code string) error {
+	step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	fresh, err := s.redis.SetNX(ctx, fmt.Sprintf("totp_used_%d_%d", userID, step), 1, 3*totp.Period).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check code: %s", err.Error())
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) user(userID int64) (*model.User, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// replaceRecoveryCodes stores a new set of recovery codes for the user and
+// returns them in plain text, the only time they are available.
+func replaceRecoveryCodes(tx *gorm.DB, userID int64) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %s", err.Error())
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]model.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, model.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %s", err.Error())
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code such as "k3q7m-a2xwp".
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %s", err.Error())
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode ignores case and dashes, so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func totpEnrollmentKey(userID int64) string {
+	return fmt.Sprintf("totp_enrollment_%d", userID)
+}
+
+func mfaChallengeKey(challenge string) string {
+	return "mfa_challenge_" + config.HashToken(challenge)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestConfirmTOTPEnrollmentLocksOut(t *testing.T) {
+	const userID = 7
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	store := memoryRedis{totpEnrollmentKey(userID): secret}
+	mfa := NewMFAService(nil, store.client(t), "Tender")
+	ctx := context.Background()
+
+	right := currentCode(t, secret)
+	wrong := "000000"
+	if wrong == right {
+		wrong = "111111"
+	}
+
+	for i := 1; i <= maxMFAAttempts; i++ {
+		if _, err := mfa.ConfirmTOTPEnrollment(ctx, userID, wrong); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("wrong code %d: got %v, want %v", i, err, ErrInvalidMFACode)
+		}
+	}
+	if _, err := mfa.ConfirmTOTPEnrollment(ctx, userID, right); !errors.Is(err, ErrTooManyMFAAttempts) {
+		t.Fatalf("right code after %d wrong ones: got %v, want %v", maxMFAAttempts, err, ErrTooManyMFAAttempts)
+	}
+	for key := range store {
+		if strings.HasPrefix(key, "totp_used_") {
+			t.Fatal("the code was checked while the user was locked out")
+		}
+	}
+}
+
+func TestLimitAttempts(t *testing.T) {
+	store := memoryRedis{}
+	mfa := NewMFAService(nil, store.client(t), "Tender")
+	ctx := context.Background()
+
+	wrong := func() error { return ErrInvalidMFACode }
+	right := func() error { return nil }
+
+	// A right code forgives the wrong ones before it
+	for i := 1; i < maxMFAAttempts; i++ {
+		if err := mfa.limitAttempts(ctx, 1, wrong); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("wrong code %d: got %v", i, err)
+		}
+	}
+	if err := mfa.limitAttempts(ctx, 1, right); err != nil {
+		t.Fatalf("right code: got %v", err)
+	}
+	if _, ok := store["mfa_attempts_1"]; ok {
+		t.Fatal("the attempts were not reset by the right code")
+	}
+
+	for i := 1; i <= maxMFAAttempts; i++ {
+		if err := mfa.limitAttempts(ctx, 1, wrong); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("wrong code %d: got %v", i, err)
+		}
+	}
+	if err := mfa.limitAttempts(ctx, 1, right); !errors.Is(err, ErrTooManyMFAAttempts) {
+		t.Fatalf("right code after the lockout: got %v, want %v", err, ErrTooManyMFAAttempts)
+	}
+	if err := mfa.limitAttempts(ctx, 2, right); err != nil {
+		t.Fatalf("another user: got %v", err)
+	}
+}
+
+// currentCode computes the TOTP code of the secret now, as in RFC 6238.
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	// Stay clear of a step boundary, where the code could change mid-test
+	if time.Now().Unix()%30 > 25 {
+		time.Sleep(5 * time.Second)
+	}
+
+	key, err := base32.StdEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var step [8]byte
+	binary.BigEndian.PutUint64(step[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(step[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// memoryRedis answers the commands of the MFA service from a map, without
+// expiry.
+type memoryRedis map[string]string
+
+func (m memoryRedis) client(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "redis.invalid:6379"})
+	client.AddHook(m)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (m memoryRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errOffline
+	}
+}
+
+func (m memoryRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		m.answer(cmd)
+		return cmd.Err()
+	}
+}
+
+func (m memoryRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			m.answer(cmd)
+		}
+		return nil
+	}
+}
+
+func (m memoryRedis) answer(cmd redis.Cmder) {
+	args := cmd.Args()
+	key := ""
+	if len(args) > 1 {
+		key, _ = args[1].(string)
+	}
+
+	switch c := cmd.(type) {
+	case *redis.StringCmd:
+		if value, ok := m[key]; ok {
+			c.SetVal(value)
+		} else {
+			c.SetErr(redis.Nil)
+		}
+	case *redis.IntCmd:
+		switch strings.ToLower(cmd.Name()) {
+		case "incr":
+			n, _ := strconv.ParseInt(m[key], 10, 64)
+			n++
+			m[key] = strconv.FormatInt(n, 10)
+			c.SetVal(n)
+		case "del":
+			var deleted int64
+			for _, arg := range args[1:] {
+				if k, _ := arg.(string); m[k] != "" {
+					delete(m, k)
+					deleted++
+				}
+			}
+			c.SetVal(deleted)
+		}
+	case *redis.BoolCmd:
+		switch strings.ToLower(cmd.Name()) {
+		case "set":
+			// SET NX
+			_, exists := m[key]
+			if !exists {
+				m[key] = "1"
+			}
+			c.SetVal(!exists)
+		case "expire":
+			_, exists := m[key]
+			c.SetVal(exists)
+		}
+	default:
+		cmd.SetErr(errOffline)
+	}
+}
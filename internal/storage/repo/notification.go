@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"tender-backend/internal/usecase/web_socket"
+	"tender-backend/model"
+)
+
+type NotificationService struct {
+	db *gorm.DB
+}
+
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{
+		db: db,
+	}
+}
+
+// Notify stores a notification for every given user and pushes it over the
+// websocket when the user is online.
+func (s *NotificationService) Notify(userIDs []int64, message string) error {
+	notifications, err := queueNotifications(s.db, userIDs, message)
+	if err != nil {
+		return err
+	}
+
+	s.Deliver(notifications)
+	return nil
+}
+
+// queueNotifications stores undelivered notifications with the given
+// transaction so they are only sent if the surrounding change commits.
+func queueNotifications(tx *gorm.DB, userIDs []int64, message string) ([]model.Notification, error) {
+	notifications := make([]model.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, model.Notification{
+			UserID:  userID,
+			Message: message,
+		})
+	}
+	if len(notifications) == 0 {
+		return notifications, nil
+	}
+
+	if err := tx.Create(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification: %s", err.Error())
+	}
+	return notifications, nil
+}
+
+// Deliver pushes queued notifications to users that are online and marks
+// them as delivered. The others stay queued in the notifications table.
+func (s *NotificationService) Deliver(notifications []model.Notification) {
+	for _, notification := range notifications {
+		if err := web_socket.SendNotification(notification.UserID, []byte(notification.Message)); err != nil {
+			continue
+		}
+
+		if err := s.db.Model(&model.Notification{}).Where("id = ?", notification.ID).Updates(map[string]interface{}{
+			"is_delivered": true,
+			"delivered_at": time.Now(),
+		}).Error; err != nil {
+			log.Printf("failed to mark notification %d as delivered: %v", notification.ID, err)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/internal/usecase/mailer"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+const (
+	OrgRoleOwner   = "owner"   // Manages the members, and everything a manager can
+	OrgRoleManager = "manager" // Acts on the organization's tenders and bids
+	OrgRoleViewer  = "viewer"  // Reads the organization's tenders and bids
+)
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	InvitationStatusRevoked  = "revoked"
+)
+
+const organizationInvitationTTL = 7 * 24 * time.Hour
+
+// What each role may do is up to the policy, see internal/pkg/policy.
+var orgRoles = map[string]bool{
+	OrgRoleViewer:  true,
+	OrgRoleManager: true,
+	OrgRoleOwner:   true,
+}
+
+var errOrganizationNotFound = errors.New("Organization not found or access denied")
+
+// OrganizationService manages organizations, their members and the
+// invitations to join them.
+type OrganizationService struct {
+	db            *gorm.DB
+	mailer        mailer.Mailer
+	linkBaseURL   string
+	notifications *NotificationService
+}
+
+func NewOrganizationService(db *gorm.DB, mail mailer.Mailer, linkBaseURL string) *OrganizationService {
+	return &OrganizationService{
+		db:            db,
+		mailer:        mail,
+		linkBaseURL:   strings.TrimSuffix(linkBaseURL, "/"),
+		notifications: NewNotificationService(db),
+	}
+}
+
+// CreateOrganization creates an organization with the user as its first owner.
+func (s *OrganizationService) CreateOrganization(userID int64, req *request_model.CreateOrganizationReq) (*model.Organization, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("invalid input: an organization name is required")
+	}
+
+	organization := model.Organization{
+		Name:      name,
+		CreatedBy: userID,
+		Members:   []model.OrganizationMember{{UserID: userID, Role: OrgRoleOwner}},
+	}
+	if err := s.db.Create(&organization).Error; err != nil {
+		return nil, fmt.Errorf("failed to create organization: %s", err.Error())
+	}
+
+	return &organization, nil
+}
+
+// GetUserOrganizations lists the organizations the user is a member of.
+func (s *OrganizationService) GetUserOrganizations(userID int64) ([]model.Organization, error) {
+	var organizations []model.Organization
+	if err := s.db.Where("id IN (?)", memberOrganizations(s.db, userID)).
+		Order("id").
+		Find(&organizations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch organizations: %s", err.Error())
+	}
+	return organizations, nil
+}
+
+// GetOrganization returns an organization with its members. Only members
+// may see it.
+func (s *OrganizationService) GetOrganization(orgID, userID int64) (*model.Organization, error) {
+	if err := authorizeOrganization(s.db, orgID, userID, policy.ActionOrganizationRead); err != nil {
+		return nil, err
+	}
+
+	var organization model.Organization
+	if err := s.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&organization, orgID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errOrganizationNotFound
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// InviteMember invites the owner of an email address to the organization and
+// emails them. The invitation is accepted from their own account, so it only
+// works for someone who verified that address.
+func (s *OrganizationService) InviteMember(ctx context.Context, orgID, ownerID int64, req *request_model.InviteMemberReq) (*model.OrganizationInvitation, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" {
+		return nil, errors.New("invalid input: an email is required")
+	}
+	if !orgRoles[req.Role] {
+		return nil, errors.New("invalid input: role must be 'owner', 'manager' or 'viewer'")
+	}
+	if err := authorizeOrganization(s.db, orgID, ownerID, policy.ActionOrganizationManage); err != nil {
+		return nil, err
+	}
+
+	var organization model.Organization
+	if err := s.db.First(&organization, orgID).Error; err != nil {
+		return nil, err
+	}
+
+	var (
+		invitation    model.OrganizationInvitation
+		notifications []model.Notification
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var members int64
+		if err := tx.Model(&model.OrganizationMember{}).
+			Joins("JOIN users ON users.id = organization_members.user_id").
+			Where("organization_members.organization_id = ? AND LOWER(users.email) = ?", orgID, email).
+			Count(&members).Error; err != nil {
+			return err
+		}
+		if members > 0 {
+			return errors.New("User is already a member of the organization")
+		}
+
+		// A new invitation replaces a pending one to the same address
+		if err := tx.Model(&model.OrganizationInvitation{}).
+			Where("organization_id = ? AND email = ? AND status = ?", orgID, email, InvitationStatusPending).
+			Updates(map[string]interface{}{"status": InvitationStatusRevoked, "responded_at": time.Now()}).Error; err != nil {
+			return err
+		}
+
+		invitation = model.OrganizationInvitation{
+			OrganizationID: orgID,
+			Email:          email,
+			Role:           req.Role,
+			Status:         InvitationStatusPending,
+			InvitedBy:      ownerID,
+			ExpiresAt:      time.Now().Add(organizationInvitationTTL),
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return fmt.Errorf("failed to create invitation: %s", err.Error())
+		}
+
+		var invitee model.User
+		err := tx.Where("LOWER(email) = ?", email).First(&invitee).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		notifications, err = queueNotifications(tx, []int64{invitee.ID},
+			fmt.Sprintf("You have been invited to join %q as %s.", organization.Name, req.Role))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.Deliver(notifications)
+
+	// The invitation is committed, so a failed email is only logged: the
+	// invitee still finds it in their list once they log in
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("Join %s", organization.Name),
+		Body: fmt.Sprintf("Hello,\n\nYou have been invited to join %s as %s. Log in with this email address to accept the invitation:\n\n%s/organizations/invitations\n\nThe invitation expires at %s.\n",
+			organization.Name, req.Role, s.linkBaseURL, invitation.ExpiresAt.Format("2006-01-02 15:04 MST")),
+	}); err != nil {
+		log.Printf("failed to send organization invitation %d: %v", invitation.ID, err)
+	}
+
+	return &invitation, nil
+}
+
+// GetInvitations lists every invitation of the organization, newest first.
+func (s *OrganizationService) GetInvitations(orgID, ownerID int64) ([]model.OrganizationInvitation, error) {
+	if err := authorizeOrganization(s.db, orgID, ownerID, policy.ActionOrganizationManage); err != nil {
+		return nil, err
+	}
+
+	var invitations []model.OrganizationInvitation
+	if err := s.db.Where("organization_id = ?", orgID).Order("id DESC").Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %s", err.Error())
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws a pending invitation.
+func (s *OrganizationService) RevokeInvitation(orgID, invitationID, ownerID int64) error {
+	if err := authorizeOrganization(s.db, orgID, ownerID, policy.ActionOrganizationManage); err != nil {
+		return err
+	}
+
+	result := s.db.Model(&model.OrganizationInvitation{}).
+		Where("id = ? AND organization_id = ? AND status = ?", invitationID, orgID, InvitationStatusPending).
+		Updates(map[string]interface{}{"status": InvitationStatusRevoked, "responded_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke invitation: %s", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("Invitation not found")
+	}
+	return nil
+}
+
+// GetUserInvitations lists the pending invitations sent to the user's email
+// address.
+func (s *OrganizationService) GetUserInvitations(userID int64) ([]model.OrganizationInvitation, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	var invitations []model.OrganizationInvitation
+	if err := s.db.Where("email = ? AND status = ? AND expires_at > ?", strings.ToLower(user.Email), InvitationStatusPending, time.Now()).
+		Order("id DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %s", err.Error())
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation makes the user a member of the organization that invited
+// their email address. The address has to be verified so that nobody can
+// claim an invitation by registering with someone else's email.
+func (s *OrganizationService) AcceptInvitation(invitationID, userID int64) (*model.OrganizationMember, error) {
+	var member model.OrganizationMember
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		invitation, err := lockUserInvitation(tx, invitationID, userID)
+		if err != nil {
+			return err
+		}
+
+		member = model.OrganizationMember{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+			Role:           invitation.Role,
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("User is already a member of the organization")
+			}
+			return fmt.Errorf("failed to add member: %s", err.Error())
+		}
+
+		return tx.Model(invitation).Updates(map[string]interface{}{
+			"status":       InvitationStatusAccepted,
+			"responded_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// DeclineInvitation turns down an invitation sent to the user's email address.
+func (s *OrganizationService) DeclineInvitation(invitationID, userID int64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		invitation, err := lockUserInvitation(tx, invitationID, userID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(invitation).Updates(map[string]interface{}{
+			"status":       InvitationStatusDeclined,
+			"responded_at": time.Now(),
+		}).Error
+	})
+}
+
+// UpdateMemberRole changes the role of a member. The last owner cannot be
+// demoted.
+func (s *OrganizationService) UpdateMemberRole(orgID, ownerID, memberID int64, role string) (*model.OrganizationMember, error) {
+	if !orgRoles[role] {
+		return nil, errors.New("invalid input: role must be 'owner', 'manager' or 'viewer'")
+	}
+
+	var member model.OrganizationMember
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+		if err := authorizeOrganization(tx, orgID, ownerID, policy.ActionOrganizationManage); err != nil {
+			return err
+		}
+
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, memberID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("Member not found")
+			}
+			return err
+		}
+		if member.Role == OrgRoleOwner && role != OrgRoleOwner {
+			if err := checkOtherOwner(tx, orgID, memberID); err != nil {
+				return err
+			}
+		}
+
+		member.Role = role
+		return tx.Model(&member).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember removes a member from the organization. Owners can remove
+// anyone, every member can leave; the last owner cannot. Tenders and bids
+// the member created stay with the organization.
+func (s *OrganizationService) RemoveMember(orgID, actorID, memberID int64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrganization(tx, orgID); err != nil {
+			return err
+		}
+		action := policy.ActionOrganizationManage
+		if actorID == memberID {
+			action = policy.ActionOrganizationLeave
+		}
+		if err := authorizeOrganization(tx, orgID, actorID, action); err != nil {
+			return err
+		}
+
+		var member model.OrganizationMember
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, memberID).First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("Member not found")
+			}
+			return err
+		}
+		if member.Role == OrgRoleOwner {
+			if err := checkOtherOwner(tx, orgID, memberID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&member).Error
+	})
+}
+
+// lockOrganization serializes membership changes of the organization, so two
+// owners cannot demote each other at the same time.
+func lockOrganization(tx *gorm.DB, orgID int64) error {
+	var organization model.Organization
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&organization, orgID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errOrganizationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func checkOtherOwner(tx *gorm.DB, orgID, userID int64) error {
+	var owners int64
+	if err := tx.Model(&model.OrganizationMember{}).
+		Where("organization_id = ? AND role = ? AND user_id <> ?", orgID, OrgRoleOwner, userID).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errors.New("An organization needs at least one owner")
+	}
+	return nil
+}
+
+// lockUserInvitation locks a pending invitation sent to the user's verified
+// email address.
+func lockUserInvitation(tx *gorm.DB, invitationID, userID int64) (*model.OrganizationInvitation, error) {
+	var user model.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.EmailVerifiedAt == nil {
+		return nil, errors.New("Email is not verified")
+	}
+
+	var invitation model.OrganizationInvitation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND email = ? AND status = ?", invitationID, strings.ToLower(user.Email), InvitationStatusPending).
+		First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Invitation not found")
+		}
+		return nil, err
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, errors.New("Invitation not found")
+	}
+	return &invitation, nil
+}
+
+// organizationRole returns the user's role in the organization, or "" when
+// they are not a member.
+func organizationRole(tx *gorm.DB, orgID, userID int64) (string, error) {
+	var member model.OrganizationMember
+	err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check membership: %s", err.Error())
+	}
+	return member.Role, nil
+}
+
+// checkActingOrganization makes sure the policy lets a user create a tender
+// or bid for the organization.
+func checkActingOrganization(tx *gorm.DB, orgID *int64, userID int64) error {
+	if orgID == nil {
+		return nil
+	}
+	allowed, err := authorize(tx, userID, policy.ActionOrganizationAct, func() ([]string, error) {
+		return organizationRelations(tx, orgID, userID, "")
+	})
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("invalid input: you do not manage this organization")
+	}
+	return nil
+}
+
+// memberOrganizations is a subquery of the IDs of the organizations the user
+// belongs to.
+func memberOrganizations(tx *gorm.DB, userID int64) *gorm.DB {
+	return tx.Model(&model.OrganizationMember{}).Select("organization_id").Where("user_id = ?", userID)
+}
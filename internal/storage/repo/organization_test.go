@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tender-backend/internal/usecase/mailer"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	return errors.New("mail server is down")
+}
+
+func TestInviteMemberSurvivesMailFailure(t *testing.T) {
+	db := testDB(t)
+	organizations := NewOrganizationService(db, failingMailer{}, "http://localhost:3000")
+
+	owner := model.User{FullName: "owner", Password: "-", Role: "client", Email: "owner@example.com", Username: "owner"}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	org := model.Organization{Name: "Buyer", CreatedBy: owner.ID, Members: []model.OrganizationMember{{UserID: owner.ID, Role: OrgRoleOwner}}}
+	if err := db.Create(&org).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	req := &request_model.InviteMemberReq{Email: "new@example.com", Role: OrgRoleManager}
+	invitation, err := organizations.InviteMember(context.Background(), org.ID, owner.ID, req)
+	if err != nil {
+		t.Fatalf("got %v, want the invitation despite the mail failure", err)
+	}
+	if invitation.ID == 0 || invitation.Status != InvitationStatusPending {
+		t.Fatalf("got %+v, want a pending invitation", invitation)
+	}
+
+	var pending int64
+	if err := db.Model(&model.OrganizationInvitation{}).
+		Where("organization_id = ? AND status = ?", org.ID, InvitationStatusPending).
+		Count(&pending).Error; err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 {
+		t.Fatalf("got %d pending invitations, want 1", pending)
+	}
+}
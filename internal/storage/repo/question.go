@@ -0,0 +1,152 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+)
+
+type QuestionService struct {
+	db            *gorm.DB
+	tenderService *TenderService
+	notifications *NotificationService
+	cutoff        time.Duration
+}
+
+func NewQuestionService(db *gorm.DB, redisClient *redis.Client, cutoff time.Duration) *QuestionService {
+	return &QuestionService{
+		db:            db,
+		tenderService: NewTenderService(db, redisClient),
+		notifications: NewNotificationService(db),
+		cutoff:        cutoff,
+	}
+}
+
+// AskQuestion stores a clarification question. Questions are accepted while
+// the tender is open and until the cutoff before its deadline.
+func (s *QuestionService) AskQuestion(tenderID, contractorID int64, text string) (*model.TenderQuestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("invalid input: question is required")
+	}
+
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tender.Status != TenderStatusOpen {
+		return nil, errors.New("Tender is not open for questions")
+	}
+	if time.Now().After(tender.Deadline.Add(-s.cutoff)) {
+		return nil, fmt.Errorf("Questions closed %s before the deadline", s.cutoff)
+	}
+
+	question := model.TenderQuestion{
+		TenderID: tenderID,
+		AskerID:  contractorID,
+		Question: text,
+	}
+	if err := s.db.Create(&question).Error; err != nil {
+		return nil, fmt.Errorf("failed to create question: %s", err.Error())
+	}
+
+	return &question, nil
+}
+
+// GetQuestions lists the questions of a tender. The tender owner sees every
+// question with its asker; contractors see published answers and their own
+// questions, with other askers anonymized.
+func (s *QuestionService) GetQuestions(tenderID, userID int64) ([]model.TenderQuestion, error) {
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := authorizeTender(s.db, tender, userID, policy.ActionTenderReadPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Where("tender_id = ?", tenderID)
+	if !owner {
+		query = query.Where("answered_at IS NOT NULL OR asker_id = ?", userID)
+	}
+
+	var questions []model.TenderQuestion
+	if err := query.Order("created_at, id").Find(&questions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch questions: %s", err.Error())
+	}
+
+	if !owner {
+		for i := range questions {
+			if questions[i].AskerID != userID {
+				questions[i].AskerID = 0
+			}
+		}
+	}
+
+	return questions, nil
+}
+
+// AnswerQuestion publishes the answer of the tender owner and notifies the
+// asker and every bidder.
+func (s *QuestionService) AnswerQuestion(tenderID, questionID, clientID int64, answer string) (*model.TenderQuestion, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, errors.New("invalid input: answer is required")
+	}
+
+	if err := s.tenderService.AuthorizeTender(tenderID, clientID, policy.ActionQuestionAnswer); err != nil {
+		return nil, err
+	}
+
+	var question model.TenderQuestion
+	if err := s.db.Where("id = ? AND tender_id = ?", questionID, tenderID).First(&question).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("Question not found")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	question.Answer = answer
+	question.AnsweredAt = &now
+	if err := s.db.Model(&question).Updates(map[string]interface{}{
+		"answer":      question.Answer,
+		"answered_at": question.AnsweredAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to answer question: %s", err.Error())
+	}
+
+	recipients, err := s.tenderService.GetBidderIDs(tenderID)
+	if err != nil {
+		log.Printf("failed to fetch bidders of tender %d: %v", tenderID, err)
+	}
+	if !containsID(recipients, question.AskerID) {
+		recipients = append(recipients, question.AskerID)
+	}
+
+	message := fmt.Sprintf("A clarification was published on tender #%d: %s", tenderID, question.Question)
+	if err := s.notifications.Notify(recipients, message); err != nil {
+		log.Printf("failed to notify about answered question %d: %v", question.ID, err)
+	}
+
+	return &question, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
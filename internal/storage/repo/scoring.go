@@ -0,0 +1,348 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tender-backend/internal/pkg/policy"
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+const (
+	CriterionPrice        = "price"
+	CriterionDeliveryTime = "delivery_time"
+	CriterionQualitative  = "qualitative"
+)
+
+// defaultCriteria is used for tenders without evaluation criteria: the
+// cheapest bid wins.
+var defaultCriteria = []model.EvaluationCriterion{
+	{Name: "Price", Kind: CriterionPrice, Weight: 1},
+}
+
+type EvaluationService struct {
+	db            *gorm.DB
+	tenderService *TenderService
+	now           func() time.Time
+}
+
+func NewEvaluationService(db *gorm.DB, redisClient *redis.Client) *EvaluationService {
+	return &EvaluationService{
+		db:            db,
+		tenderService: NewTenderService(db, redisClient),
+		now:           time.Now,
+	}
+}
+
+// validateCriteria checks the evaluation criteria of a tender. Price and
+// delivery time may each appear at most once.
+func validateCriteria(criteria []request_model.CriterionReq) error {
+	seen := map[string]bool{}
+	for _, criterion := range criteria {
+		if strings.TrimSpace(criterion.Name) == "" {
+			return errors.New("invalid input: criterion name is required")
+		}
+		if criterion.Weight <= 0 {
+			return errors.New("invalid input: criterion weight must be positive")
+		}
+
+		switch criterion.Kind {
+		case CriterionPrice, CriterionDeliveryTime:
+			if seen[criterion.Kind] {
+				return fmt.Errorf("invalid input: only one %s criterion is allowed", criterion.Kind)
+			}
+			seen[criterion.Kind] = true
+		case CriterionQualitative:
+		default:
+			return fmt.Errorf("invalid input: unknown criterion kind %q", criterion.Kind)
+		}
+	}
+	return nil
+}
+
+func criteriaFromRequest(tenderID int64, criteria []request_model.CriterionReq) []model.EvaluationCriterion {
+	result := make([]model.EvaluationCriterion, 0, len(criteria))
+	for _, criterion := range criteria {
+		result = append(result, model.EvaluationCriterion{
+			TenderID: tenderID,
+			Name:     strings.TrimSpace(criterion.Name),
+			Kind:     criterion.Kind,
+			Weight:   criterion.Weight,
+		})
+	}
+	return result
+}
+
+// SetCriteria replaces the evaluation criteria of a draft or open tender.
+func (s *EvaluationService) SetCriteria(tenderID, clientID int64, req *request_model.SetCriteriaReq) ([]model.EvaluationCriterion, error) {
+	if err := validateCriteria(req.Criteria); err != nil {
+		return nil, err
+	}
+
+	if err := s.tenderService.AuthorizeTender(tenderID, clientID, policy.ActionTenderUpdate); err != nil {
+		return nil, err
+	}
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if tender.Status != TenderStatusDraft && tender.Status != TenderStatusOpen {
+		return nil, errors.New("criteria can only be changed while the tender is draft or open")
+	}
+
+	criteria := criteriaFromRequest(tenderID, req.Criteria)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tender_id = ?", tenderID).Delete(&model.EvaluationCriterion{}).Error; err != nil {
+			return err
+		}
+		if len(criteria) == 0 {
+			return nil
+		}
+		return tx.Create(&criteria).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save criteria: %s", err.Error())
+	}
+
+	// Criteria are part of the cached tender listing
+	s.tenderService.clearTendersCache()
+
+	return criteria, nil
+}
+
+// GetCriteria returns the evaluation criteria of a tender.
+func (s *EvaluationService) GetCriteria(tenderID int64) ([]model.EvaluationCriterion, error) {
+	if !s.tenderService.IsTenderExists(tenderID) {
+		return nil, errors.New("Tender not found or access denied")
+	}
+
+	var criteria []model.EvaluationCriterion
+	if err := s.db.Where("tender_id = ?", tenderID).Order("id").Find(&criteria).Error; err != nil {
+		return nil, err
+	}
+	return criteria, nil
+}
+
+// ScoreBid stores the qualitative scores the tender owner gave a bid.
+func (s *EvaluationService) ScoreBid(tenderID, bidID, clientID int64, req *request_model.ScoreBidReq) ([]model.BidCriterionScore, error) {
+	tender, err := s.evaluableTender(tenderID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if tender.Status == TenderStatusAwarded || tender.Status == TenderStatusAwardPending {
+		return nil, errors.New("bids of an awarded tender cannot be scored")
+	}
+
+	var bid model.Bid
+	if err := s.db.Where("id = ? AND tender_id = ? AND status <> ?", bidID, tenderID, "withdrawn").First(&bid).Error; err != nil {
+		return nil, errors.New("bid not found or access denied")
+	}
+
+	var criteria []model.EvaluationCriterion
+	if err := s.db.Where("tender_id = ? AND kind = ?", tenderID, CriterionQualitative).Find(&criteria).Error; err != nil {
+		return nil, err
+	}
+	qualitative := make(map[int64]bool, len(criteria))
+	for _, criterion := range criteria {
+		qualitative[criterion.ID] = true
+	}
+
+	scores := make([]model.BidCriterionScore, 0, len(req.Scores))
+	for criterionID, score := range req.Scores {
+		if !qualitative[criterionID] {
+			return nil, fmt.Errorf("invalid input: %d is not a qualitative criterion of this tender", criterionID)
+		}
+		if score < 0 || score > 100 {
+			return nil, errors.New("invalid input: scores must be between 0 and 100")
+		}
+		scores = append(scores, model.BidCriterionScore{BidID: bidID, CriterionID: criterionID, Score: score})
+	}
+	if len(scores) == 0 {
+		return nil, errors.New("invalid input: no scores given")
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bid_id"}, {Name: "criterion_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score"}),
+	}).Create(&scores).Error; err != nil {
+		return nil, fmt.Errorf("failed to save scores: %s", err.Error())
+	}
+
+	return scores, nil
+}
+
+// RankBids scores every bid of a closed tender, stores the result and returns
+// the bids ordered from best to worst. Once the tender is awarded the stored
+// ranking is returned unchanged so the decision stays explainable.
+func (s *EvaluationService) RankBids(tenderID, clientID int64) ([]model.BidScore, error) {
+	tender, err := s.evaluableTender(tenderID, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tender.Status == TenderStatusAwarded {
+		var stored []model.BidScore
+		if err := s.db.Where("tender_id = ?", tenderID).Order("rank").Find(&stored).Error; err != nil {
+			return nil, err
+		}
+		if len(stored) > 0 {
+			return stored, nil
+		}
+	}
+
+	var criteria []model.EvaluationCriterion
+	if err := s.db.Where("tender_id = ?", tenderID).Order("id").Find(&criteria).Error; err != nil {
+		return nil, err
+	}
+
+	var bids []model.Bid
+	if err := s.db.Where("tender_id = ? AND status <> ?", tenderID, "withdrawn").Find(&bids).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch bids: %s", err.Error())
+	}
+
+	var given []model.BidCriterionScore
+	if err := s.db.Joins("JOIN bids ON bids.id = bid_criterion_scores.bid_id").
+		Where("bids.tender_id = ?", tenderID).
+		Find(&given).Error; err != nil {
+		return nil, err
+	}
+	qualitative := make(map[int64]map[int64]float64)
+	for _, score := range given {
+		if qualitative[score.BidID] == nil {
+			qualitative[score.BidID] = make(map[int64]float64)
+		}
+		qualitative[score.BidID][score.CriterionID] = score.Score
+	}
+
+	ranking := ScoreBids(criteria, bids, qualitative, s.now())
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tender_id = ?", tenderID).Delete(&model.BidScore{}).Error; err != nil {
+			return err
+		}
+		if len(ranking) == 0 {
+			return nil
+		}
+		return tx.Create(&ranking).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save ranking: %s", err.Error())
+	}
+
+	return ranking, nil
+}
+
+// evaluableTender returns a tender managed by the client whose bids may be
+// evaluated, i.e. bidding has ended.
+func (s *EvaluationService) evaluableTender(tenderID, clientID int64) (*model.Tender, error) {
+	if err := s.tenderService.AuthorizeTender(tenderID, clientID, policy.ActionTenderEvaluate); err != nil {
+		return nil, err
+	}
+	tender, err := s.tenderService.GetTenderById(tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tender.Status {
+	case TenderStatusClosed, TenderStatusEvaluating, TenderStatusAwardPending, TenderStatusAwarded:
+		return tender, nil
+	default:
+		return nil, errors.New("bids can only be evaluated after the tender is closed")
+	}
+}
+
+// ScoreBids computes the weighted score of every bid. Price and delivery time
+// are normalized against the best bid (the best gets 100, a bid twice as
+// expensive gets 50); qualitative criteria use the scores given by the client
+// and count as 0 when missing. Bids are ranked by score, then by price.
+// Weights are relative; if they add up to 0 every bid scores 0.
+func ScoreBids(criteria []model.EvaluationCriterion, bids []model.Bid, qualitative map[int64]map[int64]float64, now time.Time) []model.BidScore {
+	if len(criteria) == 0 {
+		criteria = defaultCriteria
+	}
+	if len(bids) == 0 {
+		return []model.BidScore{}
+	}
+
+	lowestPrice, fastestDelivery := bids[0].Price, bids[0].DeliveryTime
+	for _, bid := range bids[1:] {
+		lowestPrice = min(lowestPrice, bid.Price)
+		fastestDelivery = min(fastestDelivery, bid.DeliveryTime)
+	}
+
+	var totalWeight float64
+	for _, criterion := range criteria {
+		totalWeight += criterion.Weight
+	}
+
+	scores := make([]model.BidScore, 0, len(bids))
+	for _, bid := range bids {
+		score := model.BidScore{
+			TenderID:     bid.TenderID,
+			BidID:        bid.ID,
+			ContractorID: bid.ContractorID,
+			Price:        bid.Price,
+			DeliveryTime: bid.DeliveryTime,
+			Criteria:     make([]model.CriterionResult, 0, len(criteria)),
+			ComputedAt:   now,
+		}
+
+		var weighted float64
+		for _, criterion := range criteria {
+			var value float64
+			switch criterion.Kind {
+			case CriterionPrice:
+				value = relativeScore(lowestPrice, bid.Price)
+			case CriterionDeliveryTime:
+				value = relativeScore(float64(fastestDelivery), float64(bid.DeliveryTime))
+			case CriterionQualitative:
+				value = qualitative[bid.ID][criterion.ID]
+			}
+
+			weighted += value * criterion.Weight
+			score.Criteria = append(score.Criteria, model.CriterionResult{
+				CriterionID: criterion.ID,
+				Name:        criterion.Name,
+				Kind:        criterion.Kind,
+				Weight:      criterion.Weight,
+				Score:       value,
+			})
+		}
+		if totalWeight > 0 {
+			score.Score = weighted / totalWeight
+		}
+
+		scores = append(scores, score)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		if scores[i].Price != scores[j].Price {
+			return scores[i].Price < scores[j].Price
+		}
+		return scores[i].BidID < scores[j].BidID
+	})
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+
+	return scores
+}
+
+// relativeScore scores a value against the best (lowest) one: the best gets
+// 100, twice the best gets 50. A value of 0 cannot be beaten and gets 100.
+func relativeScore(best, value float64) float64 {
+	if value <= 0 {
+		return 100
+	}
+	return 100 * best / value
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tender-backend/model"
+)
+
+func TestScoreBids(t *testing.T) {
+	price := func(weight float64) model.EvaluationCriterion {
+		return model.EvaluationCriterion{ID: 1, Name: "Price", Kind: CriterionPrice, Weight: weight}
+	}
+	delivery := func(weight float64) model.EvaluationCriterion {
+		return model.EvaluationCriterion{ID: 2, Name: "Delivery", Kind: CriterionDeliveryTime, Weight: weight}
+	}
+	quality := func(weight float64) model.EvaluationCriterion {
+		return model.EvaluationCriterion{ID: 3, Name: "Quality", Kind: CriterionQualitative, Weight: weight}
+	}
+	bid := func(id int64, price float64, deliveryTime int) model.Bid {
+		return model.Bid{ID: id, TenderID: 1, ContractorID: 100 + id, Price: price, DeliveryTime: deliveryTime}
+	}
+
+	// ranked is a bid's expected place: its ID and total score, best first
+	type ranked struct {
+		bidID int64
+		score float64
+	}
+
+	tests := []struct {
+		name        string
+		criteria    []model.EvaluationCriterion
+		bids        []model.Bid
+		qualitative map[int64]map[int64]float64
+		want        []ranked
+	}{
+		{
+			name: "no bids",
+			want: []ranked{},
+		},
+		{
+			name: "without criteria the cheapest bid wins",
+			bids: []model.Bid{bid(1, 200, 10), bid(2, 100, 30)},
+			want: []ranked{{2, 100}, {1, 50}},
+		},
+		{
+			name:     "price is normalized against the cheapest bid",
+			criteria: []model.EvaluationCriterion{price(1)},
+			bids:     []model.Bid{bid(1, 400, 10), bid(2, 100, 10), bid(3, 200, 10)},
+			want:     []ranked{{2, 100}, {3, 50}, {1, 25}},
+		},
+		{
+			name:     "delivery time is normalized against the fastest bid",
+			criteria: []model.EvaluationCriterion{delivery(1)},
+			bids:     []model.Bid{bid(1, 100, 20), bid(2, 100, 5), bid(3, 100, 10)},
+			want:     []ranked{{2, 100}, {3, 50}, {1, 25}},
+		},
+		{
+			name:        "qualitative criteria use the client's scores",
+			criteria:    []model.EvaluationCriterion{quality(1)},
+			bids:        []model.Bid{bid(1, 100, 10), bid(2, 100, 10)},
+			qualitative: map[int64]map[int64]float64{1: {3: 40}, 2: {3: 90}},
+			want:        []ranked{{2, 90}, {1, 40}},
+		},
+		{
+			name:        "missing qualitative scores count as 0",
+			criteria:    []model.EvaluationCriterion{price(1), quality(1)},
+			bids:        []model.Bid{bid(1, 100, 10), bid(2, 100, 10)},
+			qualitative: map[int64]map[int64]float64{2: {3: 80}},
+			want:        []ranked{{2, 90}, {1, 50}},
+		},
+		{
+			name:        "weights decide between price and quality",
+			criteria:    []model.EvaluationCriterion{price(3), quality(1)},
+			bids:        []model.Bid{bid(1, 100, 10), bid(2, 200, 10)},
+			qualitative: map[int64]map[int64]float64{1: {3: 20}, 2: {3: 100}},
+			// (3*100 + 20) / 4 and (3*50 + 100) / 4
+			want: []ranked{{1, 80}, {2, 62.5}},
+		},
+		{
+			name:        "weights are relative",
+			criteria:    []model.EvaluationCriterion{price(0.75), quality(0.25)},
+			bids:        []model.Bid{bid(1, 100, 10), bid(2, 200, 10)},
+			qualitative: map[int64]map[int64]float64{1: {3: 20}, 2: {3: 100}},
+			want:        []ranked{{1, 80}, {2, 62.5}},
+		},
+		{
+			name:     "all criteria combined",
+			criteria: []model.EvaluationCriterion{price(2), delivery(1), quality(1)},
+			bids:     []model.Bid{bid(1, 100, 20), bid(2, 125, 10)},
+			qualitative: map[int64]map[int64]float64{
+				1: {3: 60},
+				2: {3: 100},
+			},
+			// (2*100 + 50 + 60) / 4 and (2*80 + 100 + 100) / 4
+			want: []ranked{{2, 90}, {1, 77.5}},
+		},
+		{
+			name:        "equal scores are ranked by price",
+			criteria:    []model.EvaluationCriterion{price(1), quality(1)},
+			bids:        []model.Bid{bid(1, 200, 10), bid(2, 100, 10)},
+			qualitative: map[int64]map[int64]float64{1: {3: 50}},
+			// (50 + 50) / 2 and (100 + 0) / 2
+			want: []ranked{{2, 50}, {1, 50}},
+		},
+		{
+			name:     "equal scores and prices are ranked by bid",
+			criteria: []model.EvaluationCriterion{price(1)},
+			bids:     []model.Bid{bid(3, 100, 10), bid(1, 100, 10), bid(2, 100, 10)},
+			want:     []ranked{{1, 100}, {2, 100}, {3, 100}},
+		},
+		{
+			name:     "a single bid is the best",
+			criteria: []model.EvaluationCriterion{price(1), delivery(1)},
+			bids:     []model.Bid{bid(1, 300, 40)},
+			want:     []ranked{{1, 100}},
+		},
+		{
+			name:     "a price of 0 cannot be beaten",
+			criteria: []model.EvaluationCriterion{price(1)},
+			bids:     []model.Bid{bid(1, 100, 10), bid(2, 0, 10)},
+			want:     []ranked{{2, 100}, {1, 0}},
+		},
+		{
+			name:     "all prices and delivery times 0",
+			criteria: []model.EvaluationCriterion{price(1), delivery(1)},
+			bids:     []model.Bid{bid(2, 0, 0), bid(1, 0, 0)},
+			want:     []ranked{{1, 100}, {2, 100}},
+		},
+		{
+			name:        "weights adding up to 0 score every bid 0",
+			criteria:    []model.EvaluationCriterion{price(0), quality(0)},
+			bids:        []model.Bid{bid(1, 200, 10), bid(2, 100, 10)},
+			qualitative: map[int64]map[int64]float64{1: {3: 100}},
+			want:        []ranked{{2, 0}, {1, 0}},
+		},
+	}
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := ScoreBids(tt.criteria, tt.bids, tt.qualitative, now)
+			if scores == nil {
+				t.Fatal("got nil, want an empty ranking")
+			}
+			if len(scores) != len(tt.want) {
+				t.Fatalf("got %d scores, want %d", len(scores), len(tt.want))
+			}
+
+			criteria := len(tt.criteria)
+			if criteria == 0 {
+				criteria = len(defaultCriteria)
+			}
+			for i, score := range scores {
+				want := tt.want[i]
+				if score.BidID != want.bidID || math.Abs(score.Score-want.score) > 1e-9 {
+					t.Errorf("rank %d: got bid %d with %v, want bid %d with %v", i+1, score.BidID, score.Score, want.bidID, want.score)
+				}
+				if score.Rank != i+1 {
+					t.Errorf("bid %d: got rank %d, want %d", score.BidID, score.Rank, i+1)
+				}
+				if len(score.Criteria) != criteria {
+					t.Errorf("bid %d: got %d criterion results, want %d", score.BidID, len(score.Criteria), criteria)
+				}
+				if !score.ComputedAt.Equal(now) {
+					t.Errorf("bid %d: computed at %v, want %v", score.BidID, score.ComputedAt, now)
+				}
+			}
+		})
+	}
+}
+
+func TestScoreBidsCriterionResults(t *testing.T) {
+	criteria := []model.EvaluationCriterion{
+		{ID: 1, Name: "Price", Kind: CriterionPrice, Weight: 2},
+		{ID: 2, Name: "Delivery", Kind: CriterionDeliveryTime, Weight: 1},
+		{ID: 3, Name: "Quality", Kind: CriterionQualitative, Weight: 1},
+	}
+	bids := []model.Bid{
+		{ID: 1, TenderID: 7, ContractorID: 11, Price: 100, DeliveryTime: 20},
+		{ID: 2, TenderID: 7, ContractorID: 12, Price: 125, DeliveryTime: 10},
+	}
+	qualitative := map[int64]map[int64]float64{2: {3: 100}}
+
+	scores := ScoreBids(criteria, bids, qualitative, time.Now())
+
+	// Bid 1: (2*100 + 50 + 0) / 4, bid 2: (2*80 + 100 + 100) / 4
+	want := map[int64][]float64{1: {100, 50, 0}, 2: {80, 100, 100}}
+	for _, score := range scores {
+		bid := bids[score.BidID-1]
+		if score.TenderID != bid.TenderID || score.ContractorID != bid.ContractorID ||
+			score.Price != bid.Price || score.DeliveryTime != bid.DeliveryTime {
+			t.Errorf("bid %d: the score does not describe the bid: %+v", bid.ID, score)
+		}
+		for i, result := range score.Criteria {
+			criterion := criteria[i]
+			if result.CriterionID != criterion.ID || result.Name != criterion.Name ||
+				result.Kind != criterion.Kind || result.Weight != criterion.Weight {
+				t.Errorf("bid %d: result %d does not describe criterion %d: %+v", bid.ID, i, criterion.ID, result)
+			}
+			if math.Abs(result.Score-want[bid.ID][i]) > 1e-9 {
+				t.Errorf("bid %d, %s: got %v, want %v", bid.ID, criterion.Name, result.Score, want[bid.ID][i])
+			}
+		}
+	}
+}
@@ -4,49 +4,95 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"tender-backend/internal/pkg/policy"
 	"tender-backend/model"
 	request_model "tender-backend/model/request"
+	response_model "tender-backend/model/response"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/utils"
 )
 
 type TenderService struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db            *gorm.DB
+	redis         *redis.Client
+	notifications *NotificationService
+	standstill    time.Duration
 }
 
 // NewTenderService initializes a new TenderService with the database connection.
 func NewTenderService(db *gorm.DB, redisClient *redis.Client) *TenderService {
 	return &TenderService{
-		db:    db,
-		redis: redisClient,
+		db:            db,
+		redis:         redisClient,
+		notifications: NewNotificationService(db),
 	}
 }
 
+// SetStandstill sets how long losing bidders may appeal an award before it
+// becomes final. With no standstill awards are final immediately.
+func (t *TenderService) SetStandstill(standstill time.Duration) {
+	t.standstill = standstill
+}
+
 // CreateTender creates a new tender in the database.
 func (t *TenderService) CreateTender(req *request_model.CreateTenderReq, clientID int64) (*model.Tender, error) {
 	if err := validateCreateTender(req); err != nil {
 		return nil, err
 	}
+	if err := checkActingOrganization(t.db, req.OrganizationID, clientID); err != nil {
+		return nil, err
+	}
 
 	tender := &model.Tender{
-		ClientID:    clientID,
-		Title:       req.Title,
-		Description: req.Description,
-		Deadline:    req.Deadline,
-		Budget:      req.Budget,
-		Status:      "open",
+		ClientID:           clientID,
+		OrganizationID:     req.OrganizationID,
+		Title:              req.Title,
+		Description:        req.Description,
+		Deadline:           req.Deadline,
+		Budget:             req.Budget,
+		Status:             TenderStatusOpen,
+		GracePeriodMinutes: req.GracePeriodMinutes,
+		Language:           req.Language,
+		Sealed:             req.Sealed,
+		Auction:            req.Auction,
+		MinDecrement:       req.MinDecrement,
+		ExtensionSeconds:   req.ExtensionSeconds,
+		Visibility:         req.Visibility,
+	}
+	tender.Criteria = criteriaFromRequest(0, req.Criteria)
+	for _, lot := range req.Lots {
+		tender.Lots = append(tender.Lots, model.Lot{
+			Title:       lot.Title,
+			Description: lot.Description,
+			Budget:      lot.Budget,
+		})
+	}
+	if req.Draft {
+		tender.Status = TenderStatusDraft
 	}
 
-	// Save the tender to the database.
-	if err := t.db.Create(tender).Error; err != nil {
+	// Save the tender and its initial status to the database.
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tender).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(tenderVersionSnapshot(tender, clientID, "tender created")).Error; err != nil {
+			return err
+		}
+		return recordTenderStatus(tx, tender.ID, "", tender.Status, &clientID, "tender created")
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Invalidate the cache after creating a new tender
-	t.redis.Del(context.Background(), "tenders_cache")
+	t.clearTendersCache()
 
 	return tender, nil
 }
@@ -62,6 +108,61 @@ func validateCreateTender(req *request_model.CreateTenderReq) error {
 	if req.Budget <= 0 {
 		return errors.New("invalid input: budget must be positive")
 	}
+	if req.GracePeriodMinutes < 0 {
+		return errors.New("invalid input: grace period cannot be negative")
+	}
+	if req.Auction && req.Sealed {
+		return errors.New("invalid input: an auction cannot be sealed")
+	}
+	if req.MinDecrement < 0 || req.ExtensionSeconds < 0 {
+		return errors.New("invalid input: auction settings cannot be negative")
+	}
+	if req.Auction && req.MinDecrement == 0 {
+		return errors.New("invalid input: an auction needs a positive minimum decrement")
+	}
+	if err := validateLots(req); err != nil {
+		return err
+	}
+	if err := validateCriteria(req.Criteria); err != nil {
+		return err
+	}
+	if req.Language == "" {
+		req.Language = "en"
+	}
+	if !utils.Contains(tenderLanguages, req.Language) {
+		return errors.New("invalid input: language must be one of en, ru, uz")
+	}
+	if req.Visibility == "" {
+		req.Visibility = TenderVisibilityPublic
+	}
+	if req.Visibility != TenderVisibilityPublic && req.Visibility != TenderVisibilityInviteOnly {
+		return errors.New("invalid input: visibility must be 'public' or 'invite_only'")
+	}
+	return nil
+}
+
+// validateLots checks the lots of a multi-lot tender.
+func validateLots(req *request_model.CreateTenderReq) error {
+	if len(req.Lots) == 0 {
+		return nil
+	}
+	if req.Auction {
+		return errors.New("invalid input: an auction cannot have lots")
+	}
+
+	var total float64
+	for _, lot := range req.Lots {
+		if lot.Title == "" {
+			return errors.New("invalid input: lot title is required")
+		}
+		if lot.Budget <= 0 {
+			return errors.New("invalid input: lot budget must be positive")
+		}
+		total += lot.Budget
+	}
+	if total > req.Budget {
+		return errors.New("invalid input: lot budgets exceed the tender budget")
+	}
 	return nil
 }
 
@@ -70,7 +171,7 @@ func (t *TenderService) GetTenderById(id int64) (*model.Tender, error) {
 	var tender model.Tender
 
 	// Try fetching the tender from the database
-	if err := t.db.First(&tender, id).Error; err != nil {
+	if err := t.db.Preload("Lots").Preload("Criteria").First(&tender, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("Tender not found or access denied")
 		}
@@ -80,94 +181,316 @@ func (t *TenderService) GetTenderById(id int64) (*model.Tender, error) {
 	return &tender, nil
 }
 
-// GetTenders retrieves all tenders from the cache or database.
-func (t *TenderService) GetTenders() ([]model.Tender, error) {
+// GetTenders retrieves a filtered, sorted page of tenders from the cache or
+// database. Invite-only tenders are only listed for their owner and invitees;
+// viewerID is zero for anonymous callers.
+func (t *TenderService) GetTenders(filter *request_model.TenderFilter, viewerID int64) (*response_model.TenderListRes, error) {
+	if err := normalizeTenderFilter(filter); err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
-	cacheKey := "tenders_cache"
+	cacheKey := tendersCacheKey(filter, viewerID)
 
-	// Try fetching the tenders from Redis
+	// Try fetching the page from Redis
 	cachedTenders, err := t.redis.Get(ctx, cacheKey).Result()
 	if err == nil && cachedTenders != "" {
-		var tenders []model.Tender
-		if err := json.Unmarshal([]byte(cachedTenders), &tenders); err == nil {
-			return tenders, nil
+		var res response_model.TenderListRes
+		if err := json.Unmarshal([]byte(cachedTenders), &res); err == nil {
+			return &res, nil
 		}
 	}
 
 	// If cache miss or unmarshal error, fetch from the database
-	var tenders []model.Tender
-	if err := t.db.Find(&tenders).Error; err != nil {
+	query := visibleTenders(t.db.Model(&model.Tender{}), viewerID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.ClientID != 0 {
+		query = query.Where("client_id = ?", filter.ClientID)
+	}
+	if filter.OrganizationID != 0 {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.MinBudget > 0 {
+		query = query.Where("budget >= ?", filter.MinBudget)
+	}
+	if filter.MaxBudget > 0 {
+		query = query.Where("budget <= ?", filter.MaxBudget)
+	}
+	if !filter.DeadlineFrom.IsZero() {
+		query = query.Where("deadline >= ?", filter.DeadlineFrom)
+	}
+	if !filter.DeadlineTo.IsZero() {
+		query = query.Where("deadline <= ?", filter.DeadlineTo)
+	}
+
+	res := response_model.TenderListRes{
+		Tenders: []model.Tender{},
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}
+	if err := query.Count(&res.Total).Error; err != nil {
 		return nil, err
 	}
 
-	// Cache the tenders for 10 minutes
-	tendersJSON, err := json.Marshal(tenders)
+	// The id tie-breaker keeps pages stable when the sort column has duplicates
+	if err := query.
+		Preload("Lots").
+		Preload("Criteria").
+		Order(fmt.Sprintf("%s %s, id %s", filter.SortBy, filter.SortOrder, filter.SortOrder)).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&res.Tenders).Error; err != nil {
+		return nil, err
+	}
+
+	// Cache the page for 10 minutes
+	resJSON, err := json.Marshal(res)
 	if err == nil {
-		_ = t.redis.Set(ctx, cacheKey, tendersJSON, 10*time.Minute).Err()
+		_ = t.redis.Set(ctx, cacheKey, resJSON, 10*time.Minute).Err()
 	}
 
-	return tenders, nil
+	return &res, nil
 }
 
-// UpdateTender updates the tender with the given ID.
-func (t *TenderService) UpdateTender(tenderID, clientID int64, req *request_model.UpdateTenderReq) (*model.Tender, error) {
-	if err := t.ValidateTenderBelongsToUser(tenderID, clientID); err != nil {
-		return nil, err
+var tenderSortFields = []string{"id", "title", "deadline", "budget", "status"}
+
+const (
+	tendersCachePrefix  = "tenders_cache"
+	defaultTendersLimit = 20
+	maxTendersLimit     = 100
+)
+
+// normalizeTenderFilter validates the filter and fills in defaults so that
+// equivalent queries share the same cache key.
+func normalizeTenderFilter(filter *request_model.TenderFilter) error {
+	filter.Status = strings.ToLower(strings.TrimSpace(filter.Status))
+	filter.SortBy = strings.ToLower(strings.TrimSpace(filter.SortBy))
+	filter.SortOrder = strings.ToLower(strings.TrimSpace(filter.SortOrder))
+
+	if filter.SortBy == "" {
+		filter.SortBy = "id"
+	}
+	if !utils.Contains(tenderSortFields, filter.SortBy) {
+		return errors.New("invalid input: unsupported sort field")
 	}
 
-	var tender model.Tender
-	if err := t.db.First(&tender, tenderID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("tender not found or access denied")
+	if filter.SortOrder == "" {
+		filter.SortOrder = "desc"
+	}
+	if filter.SortOrder != "asc" && filter.SortOrder != "desc" {
+		return errors.New("invalid input: sort order must be 'asc' or 'desc'")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTendersLimit
+	}
+	if filter.Limit > maxTendersLimit {
+		filter.Limit = maxTendersLimit
+	}
+	if filter.Offset < 0 {
+		return errors.New("invalid input: offset cannot be negative")
+	}
+
+	if filter.MinBudget < 0 || filter.MaxBudget < 0 {
+		return errors.New("invalid input: budget cannot be negative")
+	}
+	if filter.MaxBudget > 0 && filter.MinBudget > filter.MaxBudget {
+		return errors.New("invalid input: min_budget is greater than max_budget")
+	}
+	if !filter.DeadlineFrom.IsZero() && !filter.DeadlineTo.IsZero() && filter.DeadlineFrom.After(filter.DeadlineTo) {
+		return errors.New("invalid input: deadline_from is after deadline_to")
+	}
+
+	return nil
+}
+
+// tendersCacheKey builds the cache key of a normalized filter as seen by the
+// viewer. Anonymous callers share the viewer=0 pages.
+func tendersCacheKey(filter *request_model.TenderFilter, viewerID int64) string {
+	timeKey := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
 		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("%s:viewer=%d:status=%s:client=%d:org=%d:budget=%g-%g:deadline=%s-%s:sort=%s_%s:limit=%d:offset=%d",
+		tendersCachePrefix,
+		viewerID,
+		filter.Status,
+		filter.ClientID,
+		filter.OrganizationID,
+		filter.MinBudget, filter.MaxBudget,
+		timeKey(filter.DeadlineFrom), timeKey(filter.DeadlineTo),
+		filter.SortBy, filter.SortOrder,
+		filter.Limit, filter.Offset,
+	)
+}
+
+// clearTendersCache removes every cached tender listing.
+func (t *TenderService) clearTendersCache() {
+	ctx := context.Background()
+
+	iter := t.redis.Scan(ctx, 0, tendersCachePrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		_ = t.redis.Del(ctx, iter.Val()).Err()
+	}
+}
+
+var tenderLanguages = []string{"en", "ru", "uz"}
+
+// tenderTextConfigSQL picks the Postgres text search configuration matching
+// the tender language. There is no Uzbek dictionary, so "uz" uses "simple".
+const tenderTextConfigSQL = "(CASE tenders.language WHEN 'ru' THEN 'russian'::regconfig WHEN 'en' THEN 'english'::regconfig ELSE 'simple'::regconfig END)"
+
+// SearchTenders runs a ranked full-text search over the titles and
+// descriptions of the tenders the viewer may see.
+func (t *TenderService) SearchTenders(req *request_model.TenderSearchReq, viewerID int64) (*response_model.TenderSearchRes, error) {
+	req.Q = strings.TrimSpace(req.Q)
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	req.Language = strings.ToLower(strings.TrimSpace(req.Language))
+
+	if req.Q == "" {
+		return nil, errors.New("invalid input: search query is required")
+	}
+	if req.Language != "" && !utils.Contains(tenderLanguages, req.Language) {
+		return nil, errors.New("invalid input: language must be one of en, ru, uz")
+	}
+	if req.Offset < 0 {
+		return nil, errors.New("invalid input: offset cannot be negative")
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultTendersLimit
+	}
+	if req.Limit > maxTendersLimit {
+		req.Limit = maxTendersLimit
+	}
+
+	// Each row is matched with the configuration it was indexed with
+	tsQuery := fmt.Sprintf("websearch_to_tsquery(%s, ?)", tenderTextConfigSQL)
+
+	query := t.db.Table("tenders").Where(fmt.Sprintf("tenders.search_vector @@ %s", tsQuery), req.Q)
+	query = visibleTenders(query, viewerID)
+	if req.Status != "" {
+		query = query.Where("tenders.status = ?", req.Status)
+	}
+	if req.Language != "" {
+		query = query.Where("tenders.language = ?", req.Language)
+	}
+
+	res := response_model.TenderSearchRes{
+		Results: []response_model.TenderSearchHit{},
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+	}
+	if err := query.Count(&res.Total).Error; err != nil {
 		return nil, err
 	}
 
-	if err := ValidateTenderUpdate(tender.Status, req.Status); err != nil {
+	selectSQL := fmt.Sprintf(`tenders.*,
+		ts_rank(tenders.search_vector, %[1]s) AS rank,
+		ts_headline(%[2]s, tenders.title, %[1]s, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS title_highlight,
+		ts_headline(%[2]s, tenders.description, %[1]s, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15') AS snippet`,
+		tsQuery, tenderTextConfigSQL)
+
+	if err := query.
+		Select(selectSQL, req.Q, req.Q, req.Q).
+		Order("rank DESC, tenders.id DESC").
+		Limit(req.Limit).
+		Offset(req.Offset).
+		Scan(&res.Results).Error; err != nil {
 		return nil, err
 	}
 
-	tender.Status = req.Status
+	return &res, nil
+}
 
-	// Save the updated tender to the database
-	if err := t.db.Save(&tender).Error; err != nil {
+// UpdateTender moves the tender with the given ID to a new status.
+func (t *TenderService) UpdateTender(tenderID, clientID int64, req *request_model.UpdateTenderReq) (*model.Tender, error) {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderUpdate); err != nil {
 		return nil, err
 	}
 
-	// Invalidate the cache after updating the tender
-	t.redis.Del(context.Background(), "tenders_cache")
+	// Awarding goes through AwardTender so the winning bid is recorded
+	if req.Status == TenderStatusAwarded || req.Status == TenderStatusAwardPending {
+		return nil, fmt.Errorf("invalid input: status cannot be updated to '%s'", req.Status)
+	}
 
-	return &tender, nil
+	return t.setTenderStatus(tenderID, clientID, req.Status, req.Reason)
 }
 
-func ValidateTenderUpdate(existingStatus, newStatus string) error {
-	if existingStatus != "open" {
-		return errors.New("updates are only allowed for tenders with 'open' status")
+// GetTenderHistory returns the status transitions of a tender, oldest first.
+func (t *TenderService) GetTenderHistory(tenderID, clientID int64) ([]model.TenderStatusHistory, error) {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderReadPrivate); err != nil {
+		return nil, err
 	}
-	if newStatus == "awarded" {
-		return errors.New("status cannot be updated to 'awarded'")
+
+	var history []model.TenderStatusHistory
+	if err := t.db.Where("tender_id = ?", tenderID).Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, err
 	}
-	return nil
+
+	return history, nil
 }
 
-// DeleteTender deletes a tender by its ID.
+// DeleteTender withdraws a tender by cancelling it, so its bids, awards and
+// status history are kept.
 func (t *TenderService) DeleteTender(tenderID, clientID int64) error {
-	if err := t.ValidateTenderBelongsToUser(tenderID, clientID); err != nil {
+	if err := t.AuthorizeTender(tenderID, clientID, policy.ActionTenderDelete); err != nil {
 		return err
 	}
 
-	if err := t.db.Delete(&model.Tender{}, tenderID).Error; err != nil {
-		return err
+	_, err := t.setTenderStatus(tenderID, clientID, TenderStatusCancelled, "tender deleted by the client")
+	return err
+}
+
+// setTenderStatus moves the tender through the state machine on behalf of the
+// client. A pending award is cancelled along with the tender.
+func (t *TenderService) setTenderStatus(tenderID, clientID int64, to, reason string) (*model.Tender, error) {
+	var tender model.Tender
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&tender, tenderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("tender not found or access denied")
+			}
+			return err
+		}
+
+		if tender.Status == TenderStatusAwardPending {
+			// Re-evaluation goes through CancelAward so bidders are told why
+			if to != TenderStatusCancelled {
+				return &InvalidTransitionError{From: tender.Status, To: to}
+			}
+			if err := cancelPendingAwards(tx, &tender, "tender cancelled"); err != nil {
+				return err
+			}
+		}
+
+		// Closing a sealed tender opens its bids, which must wait for the deadline
+		if tender.Sealed && tender.Status == TenderStatusOpen && to == TenderStatusClosed {
+			opensAt := tender.Deadline.Add(time.Duration(tender.GracePeriodMinutes) * time.Minute)
+			if time.Now().Before(opensAt) {
+				return &SealedTenderError{TenderID: tender.ID, OpensAt: opensAt}
+			}
+		}
+
+		return transitionTender(tx, &tender, to, &clientID, reason)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Invalidate the cache after deleting the tender
-	t.redis.Del(context.Background(), "tenders_cache")
+	// Invalidate the cache after updating the tender
+	t.clearTendersCache()
 
-	return nil
+	return &tender, nil
 }
 
-// ValidateTenderBelongsToUser ensures that a tender belongs to a specific client.
-func (t *TenderService) ValidateTenderBelongsToUser(tenderID, clientID int64) error {
+// AuthorizeTender ensures that the policy lets the user perform the action on
+// the tender. A tender the user may not act on looks missing.
+func (t *TenderService) AuthorizeTender(tenderID, userID int64, action string) error {
 	var tender model.Tender
 
 	if err := t.db.First(&tender, tenderID).Error; err != nil {
@@ -177,33 +500,24 @@ func (t *TenderService) ValidateTenderBelongsToUser(tenderID, clientID int64) er
 		return err
 	}
 
-	if tender.ClientID != clientID {
+	allowed, err := authorizeTender(t.db, &tender, userID, action)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return errors.New("Tender not found or access denied")
 	}
 
 	return nil
 }
 
-func (t *TenderService) AwardTender(tenderID, clientID, bidID int64) error {
-	if err := t.ValidateTenderBelongsToUser(tenderID, clientID); err != nil {
-		return err
+// clearBidsCache removes the cached bid list of the tender and the given cached bids.
+func (t *TenderService) clearBidsCache(tenderID int64, bidIDs []int64) {
+	keys := []string{fmt.Sprintf("bids_tender_%d", tenderID)}
+	for _, bidID := range bidIDs {
+		keys = append(keys, fmt.Sprintf("bid_%d_tender_%d", bidID, tenderID))
 	}
-
-	if err := t.ValidateBidBelongsToTender(bidID, tenderID); err != nil {
-		return err
-	}
-
-	if err := t.db.Model(&model.Tender{}).Where("id = ?", tenderID).Updates(map[string]interface{}{
-		"status":                "awarded",
-		"awarded_contractor_id": bidID,
-	}).Error; err != nil {
-		return err
-	}
-
-	// Invalidate the cache after awarding the tender
-	t.redis.Del(context.Background(), "tenders_cache")
-
-	return nil
+	_ = t.redis.Del(context.Background(), keys...).Err()
 }
 
 func (t *TenderService) ValidateBidBelongsToTender(bidID, tenderID int64) error {
@@ -234,3 +548,48 @@ func (t *TenderService) IsTenderExists(tenderID int64) bool {
 	return true
 }
 
+// CloseExpiredTenders moves every open tender whose deadline and grace period
+// have passed to "closed" and returns the tenders it closed. Rows are locked with SKIP LOCKED
+// so two replicas running at the same time never close the same tender twice.
+func (t *TenderService) CloseExpiredTenders(now time.Time) ([]model.Tender, error) {
+	var tenders []model.Tender
+
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND deadline + make_interval(mins => grace_period_minutes) <= ?", TenderStatusOpen, now).
+			Find(&tenders).Error; err != nil {
+			return err
+		}
+
+		for i := range tenders {
+			if err := transitionTender(tx, &tenders[i], TenderStatusClosed, nil, "deadline passed"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenders) > 0 {
+		// Invalidate the cache after closing tenders
+		t.clearTendersCache()
+	}
+
+	return tenders, nil
+}
+
+// GetBidderIDs returns the distinct contractors that have a bid on the tender
+// which has not been withdrawn.
+func (t *TenderService) GetBidderIDs(tenderID int64) ([]int64, error) {
+	var contractorIDs []int64
+	if err := t.db.Model(&model.Bid{}).
+		Where("tender_id = ? AND status <> ?", tenderID, "withdrawn").
+		Distinct().
+		Pluck("contractor_id", &contractorIDs).Error; err != nil {
+		return nil, err
+	}
+
+	return contractorIDs, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"tender-backend/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tender statuses. The lifecycle is draft → open → closed → evaluating →
+// award_pending → awarded, and any non-final status can be cancelled. A
+// pending award that is cancelled sends the tender back to evaluating.
+const (
+	TenderStatusDraft        = "draft"
+	TenderStatusOpen         = "open"
+	TenderStatusClosed       = "closed"
+	TenderStatusEvaluating   = "evaluating"
+	TenderStatusAwardPending = "award_pending"
+	TenderStatusAwarded      = "awarded"
+	TenderStatusCancelled    = "cancelled"
+)
+
+var tenderTransitions = map[string][]string{
+	TenderStatusDraft:        {TenderStatusOpen, TenderStatusCancelled},
+	TenderStatusOpen:         {TenderStatusClosed, TenderStatusCancelled},
+	TenderStatusClosed:       {TenderStatusEvaluating, TenderStatusCancelled},
+	TenderStatusEvaluating:   {TenderStatusAwardPending, TenderStatusCancelled},
+	TenderStatusAwardPending: {TenderStatusAwarded, TenderStatusEvaluating, TenderStatusCancelled},
+	TenderStatusAwarded:      {},
+	TenderStatusCancelled:    {},
+}
+
+// IsTenderStatus reports whether the status is part of the tender lifecycle.
+func IsTenderStatus(status string) bool {
+	_, ok := tenderTransitions[status]
+	return ok
+}
+
+// ValidateTenderTransition checks that a tender may move from one status to another.
+func ValidateTenderTransition(from, to string) error {
+	if !IsTenderStatus(to) {
+		return &InvalidTransitionError{From: from, To: to}
+	}
+	for _, next := range tenderTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return &InvalidTransitionError{From: from, To: to}
+}
+
+// transitionTender moves the tender to a new status inside the given
+// transaction and records the change in the status history. The update is
+// conditional on the current status so concurrent transitions cannot both win.
+// A nil actor means the change was made by the system.
+func transitionTender(tx *gorm.DB, tender *model.Tender, to string, actorID *int64, reason string) error {
+	if err := ValidateTenderTransition(tender.Status, to); err != nil {
+		return err
+	}
+
+	result := tx.Model(&model.Tender{}).
+		Where("id = ? AND status = ?", tender.ID, tender.Status).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tender %d was modified concurrently", tender.ID)
+	}
+
+	from := tender.Status
+	tender.Status = to
+
+	if err := recordTenderStatus(tx, tender.ID, from, to, actorID, reason); err != nil {
+		return err
+	}
+
+	// Closing a sealed tender is the bid opening: from now on bids are visible
+	if tender.Sealed && from == TenderStatusOpen && to == TenderStatusClosed {
+		return openSealedBids(tx, tender.ID)
+	}
+	return nil
+}
+
+func openSealedBids(tx *gorm.DB, tenderID int64) error {
+	opening := model.BidOpening{
+		TenderID: tenderID,
+		OpenedAt: time.Now(),
+	}
+	if err := tx.Model(&model.Bid{}).Where("tender_id = ? AND status <> ?", tenderID, "withdrawn").Count(&opening.BidCount).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&opening).Error
+}
+
+func recordTenderStatus(tx *gorm.DB, tenderID int64, from, to string, actorID *int64, reason string) error {
+	return tx.Create(&model.TenderStatusHistory{
+		TenderID:   tenderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}).Error
+}
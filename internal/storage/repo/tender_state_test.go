@@ -0,0 +1,184 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tender-backend/model"
+	request_model "tender-backend/model/request"
+)
+
+func TestCloseSealedTender(t *testing.T) {
+	db := testDB(t)
+	tenders := NewTenderService(db, offlineRedis(t))
+
+	client := model.User{FullName: "client", Password: "-", Role: "client", Email: "client@example.com", Username: "client"}
+	contractor := model.User{FullName: "contractor", Password: "-", Role: "contractor", Email: "contractor@example.com", Username: "contractor"}
+	for _, u := range []*model.User{&client, &contractor} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tender := model.Tender{
+		ClientID:           client.ID,
+		Title:              "Office chairs",
+		Description:        "40 office chairs",
+		Deadline:           time.Now().Add(time.Hour),
+		GracePeriodMinutes: 10,
+		Budget:             1000,
+		Status:             TenderStatusOpen,
+		Sealed:             true,
+	}
+	if err := db.Create(&tender).Error; err != nil {
+		t.Fatal(err)
+	}
+	bid := model.Bid{TenderID: tender.ID, ContractorID: contractor.ID, Price: 900, DeliveryTime: 10, Status: "pending"}
+	if err := db.Create(&bid).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	closeTender := func() error {
+		_, err := tenders.UpdateTender(tender.ID, client.ID, &request_model.UpdateTenderReq{Status: TenderStatusClosed})
+		return err
+	}
+	assertOpen := func(t *testing.T) {
+		t.Helper()
+		var got model.Tender
+		if err := db.First(&got, tender.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != TenderStatusOpen {
+			t.Fatalf("the tender is %s, want %s", got.Status, TenderStatusOpen)
+		}
+		var openings int64
+		if err := db.Model(&model.BidOpening{}).Where("tender_id = ?", tender.ID).Count(&openings).Error; err != nil {
+			t.Fatal(err)
+		}
+		if openings != 0 {
+			t.Fatal("the bids were opened")
+		}
+	}
+
+	t.Run("before the deadline", func(t *testing.T) {
+		var sealedErr *SealedTenderError
+		if err := closeTender(); !errors.As(err, &sealedErr) {
+			t.Fatalf("got %v, want a SealedTenderError", err)
+		}
+		assertOpen(t)
+	})
+
+	t.Run("within the grace period", func(t *testing.T) {
+		if err := db.Model(&tender).Update("deadline", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+		var sealedErr *SealedTenderError
+		if err := closeTender(); !errors.As(err, &sealedErr) {
+			t.Fatalf("got %v, want a SealedTenderError", err)
+		}
+		assertOpen(t)
+	})
+
+	t.Run("after the grace period", func(t *testing.T) {
+		if err := db.Model(&tender).Update("deadline", time.Now().Add(-time.Hour)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := closeTender(); err != nil {
+			t.Fatal(err)
+		}
+		var opening model.BidOpening
+		if err := db.Where("tender_id = ?", tender.ID).First(&opening).Error; err != nil {
+			t.Fatalf("no bid opening: %v", err)
+		}
+		if opening.BidCount != 1 {
+			t.Fatalf("got %d opened bids, want 1", opening.BidCount)
+		}
+	})
+}
+
+func TestCancelSealedTenderBeforeDeadline(t *testing.T) {
+	db := testDB(t)
+	tenders := NewTenderService(db, offlineRedis(t))
+
+	client := model.User{FullName: "client", Password: "-", Role: "client", Email: "client@example.com", Username: "client"}
+	if err := db.Create(&client).Error; err != nil {
+		t.Fatal(err)
+	}
+	tender := model.Tender{
+		ClientID:    client.ID,
+		Title:       "Office chairs",
+		Description: "40 office chairs",
+		Deadline:    time.Now().Add(time.Hour),
+		Budget:      1000,
+		Status:      TenderStatusOpen,
+		Sealed:      true,
+	}
+	if err := db.Create(&tender).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := tenders.UpdateTender(tender.ID, client.ID, &request_model.UpdateTenderReq{Status: TenderStatusCancelled, Reason: "no longer needed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != TenderStatusCancelled {
+		t.Fatalf("the tender is %s, want %s", updated.Status, TenderStatusCancelled)
+	}
+}
+
+func TestDeleteTenderCancelsIt(t *testing.T) {
+	db := testDB(t)
+	tenders := NewTenderService(db, offlineRedis(t))
+
+	client := model.User{FullName: "client", Password: "-", Role: "client", Email: "client@example.com", Username: "client"}
+	contractor := model.User{FullName: "contractor", Password: "-", Role: "contractor", Email: "contractor@example.com", Username: "contractor"}
+	for _, u := range []*model.User{&client, &contractor} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tender := model.Tender{
+		ClientID:    client.ID,
+		Title:       "Office chairs",
+		Description: "40 office chairs",
+		Deadline:    time.Now().Add(time.Hour),
+		Budget:      1000,
+		Status:      TenderStatusOpen,
+	}
+	if err := db.Create(&tender).Error; err != nil {
+		t.Fatal(err)
+	}
+	bid := model.Bid{TenderID: tender.ID, ContractorID: contractor.ID, Price: 900, DeliveryTime: 10, Status: "pending"}
+	if err := db.Create(&bid).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tenders.DeleteTender(tender.ID, client.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var got model.Tender
+	if err := db.First(&got, tender.ID).Error; err != nil {
+		t.Fatalf("the tender is gone: %v", err)
+	}
+	if got.Status != TenderStatusCancelled {
+		t.Fatalf("the tender is %s, want %s", got.Status, TenderStatusCancelled)
+	}
+	if err := db.First(&model.Bid{}, bid.ID).Error; err != nil {
+		t.Fatalf("the bid is gone: %v", err)
+	}
+	var history []model.TenderStatusHistory
+	if err := db.Where("tender_id = ?", tender.ID).Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].FromStatus != TenderStatusOpen || history[0].ToStatus != TenderStatusCancelled ||
+		history[0].ActorID == nil || *history[0].ActorID != client.ID {
+		t.Fatalf("got history %+v, want the cancellation by the client", history)
+	}
+
+	var transitionErr *InvalidTransitionError
+	if err := tenders.DeleteTender(tender.ID, client.ID); !errors.As(err, &transitionErr) {
+		t.Fatalf("deleting a cancelled tender: got %v, want an InvalidTransitionError", err)
+	}
+}
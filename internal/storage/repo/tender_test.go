@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	request_model "tender-backend/model/request"
+)
+
+func TestValidateCreateTenderAuctionSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		auction      bool
+		sealed       bool
+		minDecrement float64
+		wantErr      string
+	}{
+		{"auction with a decrement", true, false, 10, ""},
+		{"auction without a decrement", true, false, 0, "invalid input: an auction needs a positive minimum decrement"},
+		{"negative decrement", true, false, -5, "invalid input: auction settings cannot be negative"},
+		{"sealed auction", true, true, 10, "invalid input: an auction cannot be sealed"},
+		{"no auction without a decrement", false, false, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := request_model.CreateTenderReq{
+				Title:        "Office chairs",
+				Deadline:     time.Now().Add(time.Hour),
+				Budget:       1000,
+				Auction:      tt.auction,
+				Sealed:       tt.sealed,
+				MinDecrement: tt.minDecrement,
+			}
+			err := validateCreateTender(&req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
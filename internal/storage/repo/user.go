@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"strings"
 	"tender-backend/model"
 	request_model "tender-backend/model/request"
 
@@ -72,6 +73,10 @@ func (s *UserService) UpdateUser(user *request_model.UpdateUserReq, id int64) (*
 	}
 
 	existingUser.FullName = user.FullName
+	// A new address has to be verified again
+	if !strings.EqualFold(existingUser.Email, user.Email) {
+		existingUser.EmailVerifiedAt = nil
+	}
 	existingUser.Email = user.Email
 
 	if err := s.db.Save(&existingUser).Error; err != nil {
@@ -81,6 +86,27 @@ func (s *UserService) UpdateUser(user *request_model.UpdateUserReq, id int64) (*
 	return &existingUser, nil
 }
 
+func (s *UserService) IsEmailVerified(id int64) (bool, error) {
+	var verified int64
+	if err := s.db.Model(&model.User{}).Where("id = ? AND email_verified_at IS NOT NULL", id).Count(&verified).Error; err != nil {
+		return false, err
+	}
+
+	return verified > 0, nil
+}
+
+func (s *UserService) UpdatePassword(id int64, hashedPassword string) error {
+	result := s.db.Model(&model.User{}).Where("id = ?", id).Update("password", hashedPassword)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
 func (s *UserService) DeleteUser(id int64) error {
 	if err := s.db.Delete(&model.User{}, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -0,0 +1,117 @@
+package file_storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"tender-backend/internal/pkg/config"
+	"testing"
+)
+
+// The S3 tests talk to a real S3-compatible server, e.g. the MinIO service of
+// docker-compose.yaml:
+//
+//	S3_TEST_ENDPOINT=http://localhost:9000 go test ./internal/usecase/file_storage
+//
+// The bucket (S3_TEST_BUCKET, default tender-attachments) must exist.
+func testS3Config(t *testing.T) config.S3Config {
+	t.Helper()
+
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	return config.S3Config{
+		Endpoint:  endpoint,
+		Bucket:    envOr("S3_TEST_BUCKET", "tender-attachments"),
+		Region:    envOr("S3_TEST_REGION", "us-east-1"),
+		AccessKey: envOr("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("S3_TEST_SECRET_KEY", "minioadmin"),
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestS3StorageRoundTrip(t *testing.T) {
+	storage, err := NewS3Storage(testS3Config(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	data := make([]byte, 256<<10)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256(data)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"plain key", "test/" + hex.EncodeToString(want[:8]) + ".pdf"},
+		{"key that needs escaping", "test/" + hex.EncodeToString(want[8:16]) + " (copy) +ü.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { _ = storage.Delete(ctx, tt.key) })
+
+			// Streamed the way uploads are: hashed on the way to the backend
+			hash := sha256.New()
+			body := io.TeeReader(bytes.NewReader(data), hash)
+			if err := storage.Put(ctx, tt.key, body, int64(len(data)), "application/pdf"); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if got := hash.Sum(nil); !bytes.Equal(got, want[:]) {
+				t.Fatalf("SHA-256 of the upload = %x, want %x", got, want)
+			}
+
+			file, err := storage.Get(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			stored, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				t.Fatalf("reading the file: %v", err)
+			}
+			if got := sha256.Sum256(stored); got != want {
+				t.Fatalf("SHA-256 of the stored file = %x, want %x", got, want)
+			}
+
+			if err := storage.Delete(ctx, tt.key); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := storage.Get(ctx, tt.key); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get after Delete: got %v, want ErrNotFound", err)
+			}
+			if err := storage.Delete(ctx, tt.key); err != nil {
+				t.Fatalf("deleting a missing file: %v", err)
+			}
+		})
+	}
+}
+
+func TestS3StorageRejectsBadSignature(t *testing.T) {
+	cfg := testS3Config(t)
+	cfg.SecretKey += "-wrong"
+	storage, err := NewS3Storage(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Put(context.Background(), "test/bad-signature.pdf", bytes.NewReader([]byte("%PDF-")), 5, "application/pdf")
+	if err == nil {
+		t.Fatal("Put with a wrong secret key succeeded")
+	}
+}
@@ -0,0 +1,30 @@
+package file_storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"tender-backend/internal/pkg/config"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// Storage keeps uploaded files under keys chosen by the caller.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage creates the backend selected in the configuration.
+func NewStorage(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir), nil
+	case "s3":
+		return NewS3Storage(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,19 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer writes emails to the application log instead of sending them.
+// It is meant for local development only.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
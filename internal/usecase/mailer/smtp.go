@@ -0,0 +1,88 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"tender-backend/internal/pkg/config"
+)
+
+// SMTPMailer sends emails through an SMTP server. The connection is upgraded
+// with STARTTLS when the server offers it, and authentication is skipped when
+// no username is configured, as for MailHog.
+type SMTPMailer struct {
+	host     string
+	addr     string
+	from     string // As shown in the From header, e.g. "Tender <noreply@example.com>"
+	sender   string // Bare address used as the envelope sender
+	username string
+	password string
+}
+
+func NewSMTPMailer(cfg config.SMTPConfig, from string) *SMTPMailer {
+	sender := from
+	if address, err := mail.ParseAddress(from); err == nil {
+		sender = address.Address
+	}
+
+	return &SMTPMailer{
+		host:     cfg.Host,
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:     from,
+		sender:   sender,
+		username: cfg.Username,
+		password: cfg.Password,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %s", err.Error())
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %s", err.Error())
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %s", err.Error())
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %s", err.Error())
+		}
+	}
+
+	if err := client.Mail(m.sender); err != nil {
+		return fmt.Errorf("failed to send email: %s", err.Error())
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("failed to send email: %s", err.Error())
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %s", err.Error())
+	}
+	if _, err := writer.Write(format(m.from, msg)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to send email: %s", err.Error())
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %s", err.Error())
+	}
+	return client.Quit()
+}
@@ -31,7 +31,8 @@ func ConnectDB() {
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 
 	if err != nil {
@@ -41,12 +42,88 @@ func ConnectDB() {
 	DB = db
 	fmt.Println("Connected to the database")
 
-	if err := DB.AutoMigrate(&model.User{}, &model.Tender{}, &model.Bid{}, &model.Notification{}); err != nil {
+	if err := migrateStatusConstraints(); err != nil {
 		log.Fatalf("Error migrating database: %v", err)
 	}
+
+	if err := migrateDuplicateBids(); err != nil {
+		log.Fatalf("Error migrating database: %v", err)
+	}
+
+	// Accounts created before email verification existed count as verified
+	backfillVerifiedEmails := DB.Migrator().HasTable(&model.User{}) &&
+		!DB.Migrator().HasColumn(&model.User{}, "EmailVerifiedAt")
+
+	if err := DB.AutoMigrate(
+		&model.User{},
+		&model.Tender{}, &model.TenderVersion{}, &model.Lot{}, &model.EvaluationCriterion{},
+		&model.Bid{}, &model.BidLot{}, &model.BidRevision{}, &model.BidCriterionScore{}, &model.BidScore{},
+		&model.Notification{}, &model.LateBidAttempt{}, &model.TenderStatusHistory{},
+		&model.BidOpening{}, &model.Attachment{}, &model.TenderQuestion{}, &model.TenderInvitation{},
+		&model.TenderAward{}, &model.TenderAppeal{}, &model.RecoveryCode{},
+		&model.Organization{}, &model.OrganizationMember{}, &model.OrganizationInvitation{},
+	); err != nil {
+		log.Fatalf("Error migrating database: %v", err)
+	}
+
+	if backfillVerifiedEmails {
+		if err := DB.Exec("UPDATE users SET email_verified_at = NOW()").Error; err != nil {
+			log.Fatalf("Error migrating database: %v", err)
+		}
+	}
 	fmt.Println("Database migrated")
 }
 
+// migrateStatusConstraints drops the status check constraints so AutoMigrate
+// recreates them from the current model definitions, and moves rows with
+// retired statuses onto their replacements.
+func migrateStatusConstraints() error {
+	if DB.Migrator().HasTable(&model.Tender{}) {
+		if err := DB.Exec("ALTER TABLE tenders DROP CONSTRAINT IF EXISTS chk_tenders_status").Error; err != nil {
+			return err
+		}
+		// "pending" was never used and is replaced by "draft"
+		if err := DB.Exec("UPDATE tenders SET status = 'draft' WHERE status = 'pending'").Error; err != nil {
+			return err
+		}
+	}
+	if DB.Migrator().HasTable(&model.Bid{}) {
+		if err := DB.Exec("ALTER TABLE bids DROP CONSTRAINT IF EXISTS chk_bids_status").Error; err != nil {
+			return err
+		}
+	}
+	// The role constraint follows the same rule since the admin role was added
+	if DB.Migrator().HasTable(&model.User{}) {
+		if err := DB.Exec("ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateDuplicateBids leaves every contractor with a single active bid per
+// tender so the idx_bids_active_contractor unique index can be created. The
+// accepted bid, or else the most recent one, is kept and older pending
+// duplicates are rejected.
+func migrateDuplicateBids() error {
+	if !DB.Migrator().HasTable(&model.Bid{}) {
+		return nil
+	}
+
+	return DB.Exec(`UPDATE bids SET status = 'rejected'
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, status, ROW_NUMBER() OVER (
+					PARTITION BY tender_id, contractor_id
+					ORDER BY status = 'accepted' DESC, id DESC
+				) AS position
+				FROM bids
+				WHERE status IN ('pending', 'accepted')
+			) ranked
+			WHERE position > 1 AND status = 'pending'
+		)`).Error
+}
+
 func CloseDB() {
 	sqlDB, err := DB.DB()
 	if err != nil {
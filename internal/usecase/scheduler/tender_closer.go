@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	server "tender-backend/internal/storage/repo"
+)
+
+const tenderCloserLockKey = "tender_closer_lock"
+
+// releaseLockScript deletes the lock only while this replica still holds it.
+// A separate GET and DEL would delete the lock of another replica that took
+// it over after ours expired in between.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// TenderCloser periodically closes open tenders whose deadline has passed and
+// finalizes awards whose standstill period has ended.
+type TenderCloser struct {
+	tenderService       *server.TenderService
+	notificationService *server.NotificationService
+	redis               *redis.Client
+	interval            time.Duration
+	instanceID          string
+}
+
+func NewTenderCloser(tenderService *server.TenderService, notificationService *server.NotificationService, redisClient *redis.Client, interval time.Duration) *TenderCloser {
+	hostname, _ := os.Hostname()
+
+	return &TenderCloser{
+		tenderService:       tenderService,
+		notificationService: notificationService,
+		redis:               redisClient,
+		interval:            interval,
+		instanceID:          fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Start runs the closer on every tick until the context is cancelled.
+func (s *TenderCloser) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx)
+		}
+	}
+}
+
+func (s *TenderCloser) run(ctx context.Context) {
+	// Only one replica performs a pass at a time. The row locks taken by
+	// CloseExpiredTenders still protect us if the lock expires mid-run.
+	acquired, err := s.redis.SetNX(ctx, tenderCloserLockKey, s.instanceID, s.interval).Result()
+	if err != nil {
+		log.Printf("Tender closer: failed to acquire lock: %v", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer s.releaseLock(ctx)
+
+	tenders, err := s.tenderService.CloseExpiredTenders(time.Now())
+	if err != nil {
+		log.Printf("Tender closer: failed to close expired tenders: %v", err)
+		return
+	}
+
+	for _, tender := range tenders {
+		recipients := []int64{tender.ClientID}
+
+		bidderIDs, err := s.tenderService.GetBidderIDs(tender.ID)
+		if err != nil {
+			log.Printf("Tender closer: failed to fetch bidders of tender %d: %v", tender.ID, err)
+		}
+		recipients = append(recipients, bidderIDs...)
+
+		message := fmt.Sprintf("Tender #%d %q has been closed: the deadline has passed", tender.ID, tender.Title)
+		if err := s.notificationService.Notify(recipients, message); err != nil {
+			log.Printf("Tender closer: failed to notify about tender %d: %v", tender.ID, err)
+		}
+	}
+
+	// Bidders are notified about final awards by FinalizeAwards itself
+	awarded, err := s.tenderService.FinalizeAwards(time.Now())
+	if err != nil {
+		log.Printf("Tender closer: failed to finalize awards: %v", err)
+		return
+	}
+	for _, tender := range awarded {
+		if err := s.notificationService.Notify([]int64{tender.ClientID},
+			fmt.Sprintf("The award of tender #%d %q is now final", tender.ID, tender.Title)); err != nil {
+			log.Printf("Tender closer: failed to notify about tender %d: %v", tender.ID, err)
+		}
+	}
+}
+
+func (s *TenderCloser) releaseLock(ctx context.Context) {
+	if err := releaseLockScript.Run(ctx, s.redis, []string{tenderCloserLockKey}, s.instanceID).Err(); err != nil {
+		log.Printf("Tender closer: failed to release lock: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package web_socket
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// AuctionRoom groups the connections of everyone following a live auction.
+type AuctionRoom struct {
+	TenderID     int64
+	participants map[int64]*websocket.Conn // map[user_id]connection
+	lock         sync.Mutex
+}
+
+var rooms = make(map[int64]*AuctionRoom) // map[tender_id]room
+
+// roomsLock guards rooms and is held while participants join and leave, so a
+// room is never dropped between a join looking it up and adding itself. It is
+// always taken before a room's lock.
+var roomsLock sync.Mutex
+
+// JoinAuction adds the connection to the room of the tender, creating the room
+// when it is the first participant. A user joining twice replaces the older
+// connection.
+func JoinAuction(tenderID, userID int64, conn *websocket.Conn) *AuctionRoom {
+	roomsLock.Lock()
+	defer roomsLock.Unlock()
+
+	room, exists := rooms[tenderID]
+	if !exists {
+		room = &AuctionRoom{
+			TenderID:     tenderID,
+			participants: make(map[int64]*websocket.Conn),
+		}
+		rooms[tenderID] = room
+	}
+
+	room.lock.Lock()
+	if old, ok := room.participants[userID]; ok && old != conn {
+		_ = old.Close()
+	}
+	room.participants[userID] = conn
+	room.lock.Unlock()
+
+	return room
+}
+
+// Leave removes the user from the room and drops the room once it is empty.
+func (r *AuctionRoom) Leave(userID int64, conn *websocket.Conn) {
+	roomsLock.Lock()
+	defer roomsLock.Unlock()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.participants[userID] == conn {
+		delete(r.participants, userID)
+	}
+	if len(r.participants) == 0 && rooms[r.TenderID] == r {
+		delete(rooms, r.TenderID)
+	}
+}
+
+// Send writes a message to a single participant.
+func (r *AuctionRoom) Send(userID int64, message []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	conn, exists := r.participants[userID]
+	if !exists {
+		return errClientOffline
+	}
+	return conn.WriteMessage(websocket.TextMessage, message)
+}
+
+// Broadcast writes a message to every participant of the room.
+func (r *AuctionRoom) Broadcast(message []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, conn := range r.participants {
+		_ = conn.WriteMessage(websocket.TextMessage, message)
+	}
+}
@@ -9,6 +9,8 @@ import (
 var clients = make(map[int64]*websocket.Conn) // map[user_id]connection
 var lock sync.Mutex
 
+var errClientOffline = errors.New("client is not online")
+
 func RegisterClient(userID int64, conn *websocket.Conn) {
 	lock.Lock()
 	clients[userID] = conn
@@ -24,5 +26,5 @@ func SendNotification(userID int64, message []byte) error {
 		return conn.WriteMessage(websocket.TextMessage, message)
 	}
 
-	return errors.New("client is not online")
+	return errClientOffline
 }
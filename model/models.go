@@ -6,35 +6,294 @@ import (
 
 // User represents the users table.
 type User struct {
-	ID       int64  `gorm:"primaryKey;autoIncrement" json:"id"`
-	FullName string `gorm:"size:255;not null" json:"full_name"`
-	Password string `gorm:"size:255;not null" json:"password"`
-	Role     string `gorm:"size:50;not null;check:role IN ('client', 'contractor')" json:"role"` // Restrict role to "client" or "contractor"
-	Email    string `gorm:"size:255;not null;unique" json:"email"`
-	Username string `gorm:"size:255;not null;unique" json:"username"`
+	ID               int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	FullName         string     `gorm:"size:255;not null" json:"full_name"`
+	Password         string     `gorm:"size:255;not null" json:"password"`
+	Role             string     `gorm:"size:50;not null;check:role IN ('client', 'contractor', 'admin')" json:"role"` // Admins are only created with the create-admin command
+	Email            string     `gorm:"size:255;not null;unique" json:"email"`
+	Username         string     `gorm:"size:255;not null;unique" json:"username"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"` // Bidding and tender creation need a verified email
+	TOTPSecret       string     `gorm:"size:64" json:"-"`
+	TOTPEnabledAt    *time.Time `json:"totp_enabled_at"` // Login asks for a one-time code once set
+	SuspendedAt      *time.Time `json:"suspended_at"`    // Suspended users cannot log in
+	SuspensionReason string     `gorm:"type:text" json:"suspension_reason"`
+}
+
+// Organization is a company whose members share its tenders and bids.
+type Organization struct {
+	ID        int64                `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string               `gorm:"size:255;not null" json:"name"`
+	CreatedBy int64                `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time            `gorm:"autoCreateTime" json:"created_at"`
+	Members   []OrganizationMember `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE" json:"members,omitempty"`
+}
+
+// OrganizationMember gives a user a role in an organization. Owners manage
+// the members, managers the tenders and bids, and viewers can only read them.
+type OrganizationMember struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID int64     `gorm:"not null;uniqueIndex:idx_organization_members_user" json:"organization_id"`
+	UserID         int64     `gorm:"not null;uniqueIndex:idx_organization_members_user;index" json:"user_id"`
+	Role           string    `gorm:"size:20;not null;check:role IN ('owner', 'manager', 'viewer')" json:"role"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OrganizationInvitation asks whoever owns the email address to join an
+// organization with the given role.
+type OrganizationInvitation struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID int64      `gorm:"not null;index" json:"organization_id"`
+	Email          string     `gorm:"size:255;not null;index" json:"email"`
+	Role           string     `gorm:"size:20;not null;check:role IN ('owner', 'manager', 'viewer')" json:"role"`
+	Status         string     `gorm:"size:20;not null;check:status IN ('pending', 'accepted', 'declined', 'revoked')" json:"status"`
+	InvitedBy      int64      `gorm:"not null" json:"invited_by"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	RespondedAt    *time.Time `json:"responded_at,omitempty"`
+}
+
+// RecoveryCode lets a user with two-factor authentication log in without
+// their authenticator app. Every code works once; only its hash is stored.
+type RecoveryCode struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
 }
 
 // Tender represents the tenders table.
 type Tender struct {
-	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	ClientID            int64     `gorm:"not null" json:"client_id"`
-	Title               string    `gorm:"size:255;not null" json:"title"`
-	Description         string    `gorm:"type:text;not null" json:"description"`
-	Deadline            time.Time `gorm:"not null" json:"deadline"`
-	Budget              float64   `gorm:"not null" json:"budget"`
-	Status              string    `gorm:"size:50;not null;check:status IN ('open', 'closed', 'pending', 'awarded')" json:"status"` // Restrict status to predefined values
-	AwardedContractorID int64     `json:"awarded_contractor_id"`
+	ID                  int64                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	ClientID            int64                 `gorm:"not null" json:"client_id"`
+	OrganizationID      *int64                `gorm:"index" json:"organization_id,omitempty"` // Managers of the organization can act on the tender like its client
+	Title               string                `gorm:"size:255;not null" json:"title"`
+	Description         string                `gorm:"type:text;not null" json:"description"`
+	Deadline            time.Time             `gorm:"not null" json:"deadline"`
+	Budget              float64               `gorm:"not null" json:"budget"`
+	Status              string                `gorm:"size:50;not null;check:status IN ('draft', 'open', 'closed', 'evaluating', 'award_pending', 'awarded', 'cancelled')" json:"status"` // Restrict status to predefined values
+	AwardedContractorID int64                 `json:"awarded_contractor_id"`
+	StandstillEndsAt    *time.Time            `json:"standstill_ends_at,omitempty"`                                                                      // Losing bidders may appeal a pending award until then
+	Version             int                   `gorm:"not null;default:1" json:"version"`                                                                 // Incremented by every amendment
+	GracePeriodMinutes  int                   `gorm:"not null;default:0" json:"grace_period_minutes"`                                                    // Late bids are still accepted this long after the deadline
+	Sealed              bool                  `gorm:"not null;default:false" json:"sealed"`                                                              // Bid contents stay hidden until the bid opening
+	Auction             bool                  `gorm:"not null;default:false" json:"auction"`                                                             // Bids are placed live as a reverse auction
+	MinDecrement        float64               `gorm:"not null;default:0" json:"min_decrement"`                                                           // Each auction bid must undercut the lowest price by at least this much
+	ExtensionSeconds    int                   `gorm:"not null;default:0" json:"extension_seconds"`                                                       // Auction bids this close to the deadline push it back by the same amount
+	Language            string                `gorm:"size:2;not null;default:'en';check:language IN ('en', 'ru', 'uz')" json:"language"`                 // Selects the full-text search configuration
+	Visibility          string                `gorm:"size:20;not null;default:'public';check:visibility IN ('public', 'invite_only')" json:"visibility"` // Invite-only tenders are seen and bid on by invitees only
+	Lots                []Lot                 `gorm:"foreignKey:TenderID;constraint:OnDelete:CASCADE" json:"lots,omitempty"`
+	Criteria            []EvaluationCriterion `gorm:"foreignKey:TenderID;constraint:OnDelete:CASCADE" json:"criteria,omitempty"`
+	SearchVector        string                `gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector(CASE language WHEN 'ru' THEN 'russian'::regconfig WHEN 'en' THEN 'english'::regconfig ELSE 'simple'::regconfig END, title), 'A') || setweight(to_tsvector(CASE language WHEN 'ru' THEN 'russian'::regconfig WHEN 'en' THEN 'english'::regconfig ELSE 'simple'::regconfig END, description), 'B')) STORED;index:idx_tenders_search_vector,type:gin" json:"-"`
 }
 
 // Bid represents the bids table.
 type Bid struct {
-	ID           int64   `gorm:"primaryKey;autoIncrement" json:"id"`
-	TenderID     int64   `gorm:"not null" json:"tender_id"`
-	ContractorID int64   `gorm:"not null" json:"contractor_id"`
-	Price        float64 `gorm:"not null" json:"price"`
-	DeliveryTime int     `gorm:"not null" json:"delivery_time"`
-	Comments     string  `gorm:"type:text" json:"comments"`
-	Status       string  `gorm:"size:50;not null;check:status IN ('accepted', 'rejected', 'pending')" json:"status"` // Restrict status to predefined values
+	ID               int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID         int64      `gorm:"not null;uniqueIndex:idx_bids_active_contractor,where:status = 'pending' OR status = 'accepted';uniqueIndex:idx_bids_active_organization,where:status = 'pending' OR status = 'accepted'" json:"tender_id"` // A contractor, and an organization, holds at most one active bid per tender
+	ContractorID     int64      `gorm:"not null;uniqueIndex:idx_bids_active_contractor" json:"contractor_id"`
+	OrganizationID   *int64     `gorm:"index;uniqueIndex:idx_bids_active_organization" json:"organization_id,omitempty"` // Managers of the organization can act on the bid like its contractor
+	Price            float64    `gorm:"not null" json:"price"`
+	DeliveryTime     int        `gorm:"not null" json:"delivery_time"`
+	Comments         string     `gorm:"type:text" json:"comments"`
+	TenderVersion    int        `gorm:"not null;default:1" json:"tender_version"`                                                        // The tender version the bid was submitted against
+	Revision         int        `gorm:"not null;default:1" json:"revision"`                                                              // Incremented every time the contractor revises the bid
+	Status           string     `gorm:"size:50;not null;check:status IN ('accepted', 'rejected', 'pending', 'withdrawn')" json:"status"` // Restrict status to predefined values
+	WithdrawnAt      *time.Time `json:"withdrawn_at,omitempty"`
+	WithdrawalReason string     `gorm:"type:text" json:"withdrawal_reason,omitempty"`
+	Lots             []BidLot   `gorm:"foreignKey:BidID;constraint:OnDelete:CASCADE" json:"lots,omitempty"`
+	Redacted         bool       `gorm:"-" json:"redacted,omitempty"` // Set when the contents are hidden by a sealed tender
+}
+
+// BidLot is the price a bid offers for one lot of a multi-lot tender.
+type BidLot struct {
+	ID    int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	BidID int64   `gorm:"not null;uniqueIndex:idx_bid_lots_bid_lot" json:"bid_id"`
+	LotID int64   `gorm:"not null;uniqueIndex:idx_bid_lots_bid_lot" json:"lot_id"`
+	Price float64 `gorm:"not null" json:"price"`
+}
+
+// BidRevision is a previous version of a bid, stored when the contractor revises it.
+type BidRevision struct {
+	ID            int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	BidID         int64           `gorm:"not null;uniqueIndex:idx_bid_revisions_bid_revision" json:"bid_id"`
+	Revision      int             `gorm:"not null;uniqueIndex:idx_bid_revisions_bid_revision" json:"revision"`
+	TenderVersion int             `gorm:"not null" json:"tender_version"`
+	Price         float64         `gorm:"not null" json:"price"`
+	DeliveryTime  int             `gorm:"not null" json:"delivery_time"`
+	Comments      string          `gorm:"type:text" json:"comments"`
+	Lots          []RevisedBidLot `gorm:"serializer:json;type:jsonb" json:"lots,omitempty"`
+	ReplacedAt    time.Time       `gorm:"not null" json:"replaced_at"`
+}
+
+// RevisedBidLot is a lot price of a previous bid revision.
+type RevisedBidLot struct {
+	LotID int64   `json:"lot_id"`
+	Price float64 `json:"price"`
+}
+
+// BidOpening records the moment the bids of a sealed tender were revealed.
+type BidOpening struct {
+	ID       int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID int64     `gorm:"not null;uniqueIndex" json:"tender_id"`
+	BidCount int64     `gorm:"not null" json:"bid_count"`
+	OpenedAt time.Time `gorm:"not null" json:"opened_at"`
+}
+
+// TenderVersion is a snapshot of the amendable tender fields at one version.
+type TenderVersion struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID    int64     `gorm:"not null;uniqueIndex:idx_tender_versions_tender_version" json:"tender_id"`
+	Version     int       `gorm:"not null;uniqueIndex:idx_tender_versions_tender_version" json:"version"`
+	Title       string    `gorm:"size:255;not null" json:"title"`
+	Description string    `gorm:"type:text;not null" json:"description"`
+	Deadline    time.Time `gorm:"not null" json:"deadline"`
+	Budget      float64   `gorm:"not null" json:"budget"`
+	Reason      string    `gorm:"type:text" json:"reason"`
+	AmendedBy   int64     `gorm:"not null" json:"amended_by"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Lot is an independently awarded part of a tender.
+type Lot struct {
+	ID                  int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID            int64   `gorm:"not null;index" json:"tender_id"`
+	Title               string  `gorm:"size:255;not null" json:"title"`
+	Description         string  `gorm:"type:text" json:"description"`
+	Budget              float64 `gorm:"not null" json:"budget"`
+	AwardedBidID        int64   `json:"awarded_bid_id"`
+	AwardedContractorID int64   `json:"awarded_contractor_id"`
+}
+
+// EvaluationCriterion is one weighted criterion used to score the bids of a tender.
+type EvaluationCriterion struct {
+	ID       int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID int64   `gorm:"not null;index" json:"tender_id"`
+	Name     string  `gorm:"size:255;not null" json:"name"`
+	Kind     string  `gorm:"size:50;not null;check:kind IN ('price', 'delivery_time', 'qualitative')" json:"kind"` // Price and delivery time are scored automatically
+	Weight   float64 `gorm:"not null" json:"weight"`
+}
+
+// BidCriterionScore is the score from 0 to 100 a client gave a bid on a qualitative criterion.
+type BidCriterionScore struct {
+	ID          int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	BidID       int64   `gorm:"not null;uniqueIndex:idx_bid_criterion_scores_bid_criterion" json:"bid_id"`
+	CriterionID int64   `gorm:"not null;uniqueIndex:idx_bid_criterion_scores_bid_criterion" json:"criterion_id"`
+	Score       float64 `gorm:"not null" json:"score"`
+}
+
+// BidScore keeps the computed evaluation of a bid so an award can be explained later.
+type BidScore struct {
+	ID           int64             `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID     int64             `gorm:"not null;index" json:"tender_id"`
+	BidID        int64             `gorm:"not null;uniqueIndex" json:"bid_id"`
+	ContractorID int64             `gorm:"not null" json:"contractor_id"`
+	Price        float64           `gorm:"not null" json:"price"`
+	DeliveryTime int               `gorm:"not null" json:"delivery_time"`
+	Score        float64           `gorm:"not null" json:"score"`
+	Rank         int               `gorm:"not null" json:"rank"`
+	Criteria     []CriterionResult `gorm:"serializer:json;type:jsonb" json:"criteria"`
+	ComputedAt   time.Time         `gorm:"not null" json:"computed_at"`
+}
+
+// CriterionResult is the normalized score of a bid on one criterion.
+type CriterionResult struct {
+	CriterionID int64   `json:"criterion_id"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Weight      float64 `json:"weight"`
+	Score       float64 `json:"score"`
+}
+
+// TenderStatusHistory records every status transition of a tender.
+type TenderStatusHistory struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID   int64     `gorm:"not null;index" json:"tender_id"`
+	FromStatus string    `gorm:"size:50" json:"from_status"`
+	ToStatus   string    `gorm:"size:50;not null" json:"to_status"`
+	ActorID    *int64    `json:"actor_id"` // Empty when the system made the change
+	Reason     string    `gorm:"type:text" json:"reason"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (TenderStatusHistory) TableName() string {
+	return "tender_status_history"
+}
+
+// TenderQuestion is a clarification question asked by a contractor. Answers
+// are published to everyone interested in the tender.
+type TenderQuestion struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID   int64      `gorm:"not null;index" json:"tender_id"`
+	AskerID    int64      `gorm:"not null" json:"asker_id,omitempty"` // Hidden from everyone but the asker and the tender owner
+	Question   string     `gorm:"type:text;not null" json:"question"`
+	Answer     string     `gorm:"type:text" json:"answer"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	AnsweredAt *time.Time `json:"answered_at"`
+}
+
+// TenderAward is a decision to award a tender, or one lot of it, to a bid. It
+// stays pending during the standstill period and then becomes final, unless
+// it is cancelled first.
+type TenderAward struct {
+	ID               int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID         int64      `gorm:"not null;index" json:"tender_id"`
+	LotID            *int64     `json:"lot_id,omitempty"`
+	BidID            int64      `gorm:"not null" json:"bid_id"`
+	ContractorID     int64      `gorm:"not null" json:"contractor_id"`
+	Status           string     `gorm:"size:20;not null;check:status IN ('pending', 'final', 'cancelled')" json:"status"`
+	StandstillEndsAt time.Time  `gorm:"not null" json:"standstill_ends_at"`
+	AwardedBy        int64      `gorm:"not null" json:"awarded_by"`
+	CancelReason     string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty"` // When the award became final or was cancelled
+}
+
+// TenderAppeal is a losing bidder's challenge of a pending award.
+type TenderAppeal struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID     int64      `gorm:"not null;index" json:"tender_id"`
+	ContractorID int64      `gorm:"not null" json:"contractor_id"`
+	BidID        *int64     `gorm:"index" json:"bid_id,omitempty"` // The appealing bid, which may belong to an organization
+	Reason       string     `gorm:"type:text;not null" json:"reason"`
+	Status       string     `gorm:"size:20;not null;check:status IN ('open', 'upheld', 'dismissed', 'closed')" json:"status"` // "closed" when the award was cancelled before a decision
+	Resolution   string     `gorm:"type:text" json:"resolution,omitempty"`
+	ResolvedBy   *int64     `json:"resolved_by,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
+
+// TenderInvitation lets a contractor see and bid on an invite-only tender.
+type TenderInvitation struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID     int64     `gorm:"not null;uniqueIndex:idx_tender_invitations_contractor" json:"tender_id"`
+	ContractorID int64     `gorm:"not null;uniqueIndex:idx_tender_invitations_contractor;index" json:"contractor_id"`
+	InvitedBy    int64     `gorm:"not null" json:"invited_by"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// LateBidAttempt records a bid that was rejected because it arrived after the deadline.
+type LateBidAttempt struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID     int64     `gorm:"not null;index" json:"tender_id"`
+	ContractorID int64     `gorm:"not null" json:"contractor_id"`
+	Price        float64   `gorm:"not null" json:"price"`
+	Deadline     time.Time `gorm:"not null" json:"deadline"`
+	AttemptedAt  time.Time `gorm:"not null" json:"attempted_at"`
+}
+
+// Attachment is a document uploaded to a tender or, when BidID is set, to a bid.
+type Attachment struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenderID    int64     `gorm:"not null;index" json:"tender_id"`
+	BidID       *int64    `gorm:"index" json:"bid_id"`
+	UploaderID  int64     `gorm:"not null" json:"uploader_id"`
+	FileName    string    `gorm:"size:255;not null" json:"file_name"`
+	ContentType string    `gorm:"size:255;not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	SHA256      string    `gorm:"size:64;not null" json:"sha256"`
+	StorageKey  string    `gorm:"size:512;not null;unique" json:"-"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
 // Notification represents the notifications table.
@@ -20,21 +20,190 @@ type UpdateUserReq struct {
 	Email    string `json:"email"`
 }
 
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type MFACodeReq struct {
+	Code string `json:"code"` // TOTP code or recovery code
+}
+
+type LoginMFAReq struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // TOTP code or recovery code
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
 type CreateBidReq struct {
-	Price        float64 `json:"price"`
-	DeliveryTime int     `json:"delivery_time"`
-	Comments     string  `json:"comments"`
+	Price          float64     `json:"price"`
+	DeliveryTime   int         `json:"delivery_time"`
+	Comments       string      `json:"comments"`
+	Lots           []BidLotReq `json:"lots"`
+	OrganizationID *int64      `json:"organization_id"` // Bids for an organization the contractor manages
+}
+
+// BidLotReq is the price offered for one lot. The bid price is the sum of its lots.
+type BidLotReq struct {
+	LotID int64   `json:"lot_id"`
+	Price float64 `json:"price"`
 }
 
 type CreateTenderReq struct {
+	Title              string         `json:"title"`
+	Description        string         `json:"description"`
+	Deadline           time.Time      `json:"deadline"`
+	Budget             float64        `json:"budget"`
+	GracePeriodMinutes int            `json:"grace_period_minutes"`
+	Language           string         `json:"language"`
+	Draft              bool           `json:"draft"`
+	Sealed             bool           `json:"sealed"`
+	Auction            bool           `json:"auction"`
+	MinDecrement       float64        `json:"min_decrement"`
+	ExtensionSeconds   int            `json:"extension_seconds"`
+	Visibility         string         `json:"visibility"`      // "public" (default) or "invite_only"
+	OrganizationID     *int64         `json:"organization_id"` // Creates the tender for an organization the client manages
+	Lots               []CreateLotReq `json:"lots"`
+	Criteria           []CriterionReq `json:"criteria"`
+}
+
+// CriterionReq describes an evaluation criterion. Kind is "price",
+// "delivery_time" or "qualitative".
+type CriterionReq struct {
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Weight float64 `json:"weight"`
+}
+
+type SetCriteriaReq struct {
+	Criteria []CriterionReq `json:"criteria"`
+}
+
+// ScoreBidReq holds the qualitative scores (0-100) of a bid keyed by criterion ID.
+type ScoreBidReq struct {
+	Scores map[int64]float64 `json:"scores"`
+}
+
+type CreateLotReq struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Budget      float64 `json:"budget"`
+}
+
+// AmendTenderReq changes the terms of an open tender. Empty fields keep their current value.
+type AmendTenderReq struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Deadline    time.Time `json:"deadline"`
 	Budget      float64   `json:"budget"`
+	Reason      string    `json:"reason"`
+}
+
+// InviteContractorsReq names the contractors to invite by user ID, by email,
+// or both.
+type InviteContractorsReq struct {
+	ContractorIDs []int64  `json:"contractor_ids"`
+	Emails        []string `json:"emails"`
+}
+
+type CancelAwardReq struct {
+	Reason string `json:"reason"`
+}
+
+type FileAppealReq struct {
+	Reason string `json:"reason"`
+}
+
+// ResolveAppealReq decides an appeal. Decision is "upheld", which cancels the
+// pending award, or "dismissed".
+type ResolveAppealReq struct {
+	Decision   string `json:"decision"`
+	Resolution string `json:"resolution"`
+}
+
+type WithdrawBidReq struct {
+	Reason string `json:"reason"`
+}
+
+type AskQuestionReq struct {
+	Question string `json:"question"`
+}
+
+type AnswerQuestionReq struct {
+	Answer string `json:"answer"`
+}
+
+// TenderFilter holds the query parameters accepted by the tender listing.
+type TenderFilter struct {
+	Status         string    `form:"status"`
+	ClientID       int64     `form:"client_id"`
+	OrganizationID int64     `form:"organization_id"`
+	MinBudget      float64   `form:"min_budget"`
+	MaxBudget      float64   `form:"max_budget"`
+	DeadlineFrom   time.Time `form:"deadline_from"`
+	DeadlineTo     time.Time `form:"deadline_to"`
+	SortBy         string    `form:"sort_by"`
+	SortOrder      string    `form:"sort_order"`
+	Limit          int       `form:"limit"`
+	Offset         int       `form:"offset"`
+}
+
+// UserFilter holds the query parameters of the admin user listing.
+type UserFilter struct {
+	Search string `form:"search"` // Matches name, email and username
+	Role   string `form:"role"`
+	Status string `form:"status"` // "active" or "suspended"
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+type AdminActionReq struct {
+	Reason string `json:"reason"`
+}
+
+type CreateOrganizationReq struct {
+	Name string `json:"name"`
+}
+
+// InviteMemberReq invites the owner of an email address to an organization.
+// Role is "owner", "manager" or "viewer".
+type InviteMemberReq struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type UpdateMemberReq struct {
+	Role string `json:"role"`
+}
+
+// TenderSearchReq holds the query parameters of the full-text tender search.
+type TenderSearchReq struct {
+	Q        string `form:"q"`
+	Status   string `form:"status"`
+	Language string `form:"lang"`
+	Limit    int    `form:"limit"`
+	Offset   int    `form:"offset"`
 }
 
 type UpdateTenderReq struct {
 	Status string `json:"status"`
+	Reason string `json:"reason"`
 }
 
 type CreateNotificationReq struct {
@@ -1,5 +1,10 @@
 package response_model
 
+import (
+	"tender-backend/model"
+	"time"
+)
+
 type ProfileRes struct {
 	ID       int64  `json:"id"`
 	FullName string `json:"full_name"`
@@ -8,6 +13,85 @@ type ProfileRes struct {
 }
 
 type LoginRes struct {
-	Token string `json:"token"`
-	Role  string `json:"role"`
+	Token        string `json:"token"` // Access token
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"` // Unix time the access token expires at
+	Role         string `json:"role"`
+}
+
+// MFAChallengeRes is returned by login instead of tokens when the user has
+// two-factor authentication enabled.
+type MFAChallengeRes struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`  // Sent back with the one-time code
+	ExpiresAt   int64  `json:"expires_at"` // Unix time the challenge expires at
+}
+
+type TOTPEnrollmentRes struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to show as a QR code
+}
+
+type RecoveryCodesRes struct {
+	RecoveryCodes []string  `json:"recovery_codes"` // Shown only once
+	Tokens        *LoginRes `json:"tokens,omitempty"`
+}
+
+// AdminUserRes is a user as seen by administrators.
+type AdminUserRes struct {
+	ID               int64      `json:"id"`
+	FullName         string     `json:"full_name"`
+	Email            string     `json:"email"`
+	Username         string     `json:"username"`
+	Role             string     `json:"role"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	TOTPEnabled      bool       `json:"totp_enabled"`
+	SuspendedAt      *time.Time `json:"suspended_at"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+}
+
+type UserListRes struct {
+	Users  []AdminUserRes `json:"users"`
+	Total  int64          `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+type TenderListRes struct {
+	Tenders []model.Tender `json:"tenders"`
+	Total   int64          `json:"total"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+	Next    string         `json:"next,omitempty"`
+	Prev    string         `json:"prev,omitempty"`
+}
+
+type TenderSearchHit struct {
+	model.Tender
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+type TenderSearchRes struct {
+	Results []TenderSearchHit `json:"results"`
+	Total   int64             `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// AuctionState describes the live state of a reverse auction.
+type AuctionState struct {
+	TenderID     int64     `json:"tender_id"`
+	LowestPrice  float64   `json:"lowest_price"`
+	BidCount     int64     `json:"bid_count"`
+	MinDecrement float64   `json:"min_decrement"`
+	EndsAt       time.Time `json:"ends_at"`
+}
+
+// AuctionEvent is a message sent to auction participants over the websocket.
+type AuctionEvent struct {
+	Type    string        `json:"type"` // "state", "bid" or "error"
+	State   *AuctionState `json:"state,omitempty"`
+	Message string        `json:"message,omitempty"`
 }